@@ -0,0 +1,121 @@
+package flo
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// batcher is the Step registered by Builder.Batch.
+type batcher struct {
+	size    int
+	maxWait time.Duration
+	// elem is the type of data being batched. It is determined by Validate unless the batch was created by the type
+	// safe Batch func.
+	elem reflect.Type
+	// toSlice converts a batch into a []T, where T is elem.
+	toSlice func([]interface{}) interface{}
+}
+
+// Batch registers a step that groups the data it takes in into slices. A batch is sent along to the next step once it
+// holds size items, or once maxWait has passed since its first item was added, whichever comes first. A maxWait of zero
+// or less disables time based flushing. Any partial batch is sent along when the flo shuts down, unless it is aborted.
+// If the batch takes in a T, the next step should take in a []T. A batch is run by a single worker, regardless of the
+// flo's parallelism.
+//
+//  err := flo.NewBuilder(flo.WithInput(ch)).
+//             Add(parse).                  // func(context.Context, []byte) (Record, error)
+//             Batch(100, time.Second).
+//             Add(bulkInsert).             // func(context.Context, []Record) error
+//             BuildAndExecute(ctx)
+func (b *Builder) Batch(size int, maxWait time.Duration, options ...StepOption) *Builder {
+	options = append(options, WithStepParallelism(1))
+	return b.Add(&batcher{size: size, maxWait: maxWait}, options...)
+}
+
+// Batch is the type safe equivalent of Builder.Batch. Batches are built without the use of reflection.
+func Batch[T any](c Chain[T], size int, maxWait time.Duration, options ...StepOption) Chain[[]T] {
+	options = append(options, WithStepParallelism(1))
+	c.b.Add(&batcher{
+		size:    size,
+		maxWait: maxWait,
+		elem:    reflect.TypeOf((*T)(nil)).Elem(),
+		toSlice: func(items []interface{}) interface{} {
+			ts := make([]T, len(items))
+			for i := range items {
+				ts[i], _ = items[i].(T)
+			}
+			return ts
+		},
+	}, options...)
+	return Chain[[]T]{b: c.b}
+}
+
+// compile sets up how batches are converted into slices for batches whose type was determined by Validate.
+func (bt *batcher) compile() {
+	if bt.toSlice != nil {
+		return
+	}
+	sliceType := reflect.SliceOf(bt.elem)
+	bt.toSlice = func(items []interface{}) interface{} {
+		ts := reflect.MakeSlice(sliceType, len(items), len(items))
+		for i := range items {
+			if items[i] != nil {
+				ts.Index(i).Set(reflect.ValueOf(items[i]))
+			}
+		}
+		return ts.Interface()
+	}
+}
+
+// processBatch groups data into slices before sending it along. Could be any step in the flo.
+func (s *stepRunner) processBatch(ctx context.Context) {
+	bt := s.step.(*batcher)
+	var (
+		items   = make([]interface{}, 0, bt.size)
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+		if len(items) == 0 {
+			return
+		}
+		if send(ctx, s.outCh, bt.toSlice(items)) {
+			atomic.AddInt64(&s.metrics.out, 1)
+		}
+		items = make([]interface{}, 0, bt.size)
+	}
+
+	for {
+		select {
+		case input, ok := <-s.inCh:
+			if !ok {
+				flush()
+				return
+			}
+			atomic.AddInt64(&s.metrics.in, 1)
+			items = append(items, input)
+			if len(items) == 1 && bt.maxWait > 0 {
+				timer = time.NewTimer(bt.maxWait)
+				timeout = timer.C
+			}
+			if len(items) >= bt.size {
+				flush()
+			}
+		case <-timeout:
+			timer, timeout = nil, nil
+			flush()
+		case <-ctx.Done():
+			// the flo was aborted, so any partial batch is dropped
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
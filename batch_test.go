@@ -0,0 +1,98 @@
+package flo_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestBatchValidateFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *flo.Builder
+	}{
+		{"bad size", flo.NewBuilder().Add(start).Batch(0, 0).Add(sumInts)},
+		{"first step", flo.NewBuilder().Batch(1, 0).Add(sumInts)},
+		{"next step type mismatch", flo.NewBuilder().Add(start).Batch(1, 0).Add(sumInts)},
+		{"mixed types", flo.NewBuilder().Merge(startStringThing, start).Batch(1, 0).Add(end)},
+		{"input chan type mismatch", flo.NewBuilder(flo.WithInput(make(chan int)), flo.WithInput(make(chan string))).Batch(1, 0).Add(sumInts)},
+		{"output chan type mismatch", flo.NewBuilder(flo.WithOutput(make(chan int))).Add(startInt).Batch(1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestBatchValidate(t *testing.T) {
+	err := flo.NewBuilder(flo.WithOutput(make(chan []int))).Add(startInt).Batch(2, 0).Validate()
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	err = flo.NewBuilder(flo.WithInput(make(chan int))).Batch(2, 0).Add(sumInts).Validate()
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestBatchBySize(t *testing.T) {
+	inCh := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		inCh <- i
+	}
+	close(inCh)
+	outCh := make(chan []int, 3)
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithOutput(outCh)).
+		Add(addInts).
+		Batch(2, 0).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	got := fmt.Sprint(<-outCh, <-outCh, <-outCh)
+	if got != "[2 4] [6 8] [10]" {
+		t.Errorf("got %s, want [2 4] [6 8] [10]", got)
+	}
+	close(outCh)
+}
+
+func TestBatchByTime(t *testing.T) {
+	inCh := make(chan int)
+	outCh := make(chan []int, 1)
+	done := make(chan struct{})
+
+	go func() {
+		c := flo.Batch(flo.Then(flo.Input(flo.NewBuilder(), inCh), addInts), 10, time.Millisecond)
+		if err := flo.Output(c, outCh).BuildAndExecute(context.Background()); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+		close(done)
+	}()
+
+	// the input channel is still open, so batches can only be flushed by time
+	inCh <- 1
+	inCh <- 2
+	var got []int
+	for len(got) < 2 {
+		got = append(got, <-outCh...)
+	}
+	if fmt.Sprint(got) != "[2 4]" {
+		t.Errorf("got %v, want [2 4]", got)
+	}
+	close(inCh)
+	<-done
+	close(outCh)
+}
+
+func sumInts(ctx context.Context, is []int) error {
+	return nil
+}
@@ -0,0 +1,300 @@
+package flo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// brancher is implemented by steps that feed data into other flos, called branches.
+type brancher interface {
+	validate(in []reflect.Type) error
+	start(ctx context.Context)
+	awaitShutdown()
+}
+
+// broadcast is the Step registered by Builder.Broadcast. It delivers every piece of data it receives to each of its
+// branches.
+type broadcast struct {
+	branches []*Builder
+	chans    []chan interface{}
+}
+
+// Broadcast registers a set of branches that each receive every output of the previous step. A branch is a Builder
+// whose first step takes in the previous step's output type, it should not register an input channel of its own. Each
+// branch is run with its own options, so things like parallelism and error handling may be configured per branch.
+// A Broadcast must be the last step registered with a Builder.
+//
+//  archive := flo.NewBuilder().Add(compress).Add(store)
+//  index := flo.NewBuilder(flo.WithParallelism(5)).Add(index)
+//  err := flo.NewBuilder().
+//             Add(read).
+//             Broadcast(archive, index).
+//             BuildAndExecute(ctx)
+func (b *Builder) Broadcast(branches ...*Builder) *Builder {
+	return b.Add(&broadcast{branches: branches})
+}
+
+// validate makes sure each branch can take in data of the types in.
+func (bc *broadcast) validate(in []reflect.Type) error {
+	if len(bc.branches) == 0 {
+		return errBroadcastCnt
+	}
+	for i, branch := range bc.branches {
+		if branch == nil || len(branch.steps) == 0 {
+			return fmt.Errorf("branch %d: %w", i+1, errBranchStepCnt)
+		}
+		if _, err := branch.validate(in, false); err != nil {
+			return fmt.Errorf("branch %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// start launches each branch, feeding them from their own channel.
+func (bc *broadcast) start(ctx context.Context) {
+	bc.chans = make([]chan interface{}, len(bc.branches))
+	for i, branch := range bc.branches {
+		bc.chans[i] = make(chan interface{}, branch.steps[0].parallelism)
+		branch.start(ctx, bc.chans[i])
+	}
+}
+
+// awaitShutdown closes the channels that feed the branches and waits for each of them to finish processing.
+func (bc *broadcast) awaitShutdown() {
+	for i := range bc.chans {
+		close(bc.chans[i])
+	}
+	for i := range bc.branches {
+		bc.branches[i].awaitShutdown()
+	}
+}
+
+// handleFanOut copies data to each branch of a broadcast. Could only be the last step in the flo.
+func (s *stepRunner) handleFanOut(ctx context.Context, input interface{}, send sendFn) {
+	for _, ch := range s.step.(*broadcast).chans {
+		send(ctx, ch, input)
+	}
+}
+
+// DefaultRoute is a key that may be used with Builder.Route to register a branch for data whose key does not match
+// any other route.
+var DefaultRoute interface{} = defaultRoute{}
+
+type defaultRoute struct{}
+
+func (defaultRoute) String() string {
+	return "default"
+}
+
+// router is the Step registered by Builder.Route. It sends each piece of data it receives to one of its branches.
+type router struct {
+	fn     Step
+	routes map[interface{}]*Builder
+	chans  map[interface{}]chan interface{}
+}
+
+// Route registers a router Step that picks which branch each piece of data is sent to. The router must have a
+// signature of func(context.Context, T) (K, error), where K is the type of the keys in routes. Each branch is a Builder
+// whose first step takes in a T, it receives the original data, not the key. Data whose key does not match any route
+// is sent to the branch registered with DefaultRoute, or dropped if there is none. If the router returns an error the
+// data is not sent to any branch and the error handler is called. StepOptions configure the router itself, each
+// branch is run with its own options. A Route must be the last step registered with a Builder.
+//
+//  err := flo.NewBuilder(flo.WithInput(ch)).
+//             Route(byPriority, map[interface{}]*flo.Builder{
+//                 "high":           flo.NewBuilder(flo.WithParallelism(10)).Add(page),
+//                 flo.DefaultRoute: flo.NewBuilder().Add(email),
+//             }).
+//             BuildAndExecute(ctx)
+func (b *Builder) Route(fn Step, routes map[interface{}]*Builder, options ...StepOption) *Builder {
+	return b.Add(&router{fn: fn, routes: routes}, options...)
+}
+
+// validate makes sure each key matches the router's key type and each branch can take in data of the types in.
+func (r *router) validate(in []reflect.Type) error {
+	if len(r.routes) == 0 {
+		return errRouteCnt
+	}
+
+	keyType := reflect.TypeOf(r.fn).Out(0)
+	for _, key := range r.keys() {
+		branch := r.routes[key]
+		if key != DefaultRoute {
+			if t := reflect.TypeOf(key); t == nil || !typesAlign(t, keyType) {
+				return fmt.Errorf(routeKeyTypeMismatchFmt, key, t, keyType)
+			}
+		}
+		if branch == nil || len(branch.steps) == 0 {
+			return fmt.Errorf("route %v: %w", key, errBranchStepCnt)
+		}
+		if _, err := branch.validate(in, false); err != nil {
+			return fmt.Errorf("route %v: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// keys returns the keys of the router's routes in a stable order, so validation reports the same error each time.
+func (r *router) keys() []interface{} {
+	keys := make([]interface{}, 0, len(r.routes))
+	for key := range r.routes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := fmt.Sprint(keys[i]), fmt.Sprint(keys[j])
+		if ki == kj {
+			return fmt.Sprintf("%T", keys[i]) < fmt.Sprintf("%T", keys[j])
+		}
+		return ki < kj
+	})
+	return keys
+}
+
+// names returns the name each route is known by in stats and reports. Routes are named after their key, along with the
+// key's type should two keys look the same, such as DefaultRoute and a route keyed "default".
+func (r *router) names() map[interface{}]string {
+	counts := make(map[string]int, len(r.routes))
+	for key := range r.routes {
+		counts[fmt.Sprint(key)]++
+	}
+	names := make(map[interface{}]string, len(r.routes))
+	for key := range r.routes {
+		name := fmt.Sprint(key)
+		if counts[name] > 1 {
+			name = fmt.Sprintf("%v (%T)", key, key)
+		}
+		names[key] = name
+	}
+	return names
+}
+
+// start launches each branch, feeding them from their own channel.
+func (r *router) start(ctx context.Context) {
+	r.chans = make(map[interface{}]chan interface{}, len(r.routes))
+	for key, branch := range r.routes {
+		r.chans[key] = make(chan interface{}, branch.steps[0].parallelism)
+		branch.start(ctx, r.chans[key])
+	}
+}
+
+// awaitShutdown closes the channels that feed the branches and waits for each of them to finish processing.
+func (r *router) awaitShutdown() {
+	for key := range r.chans {
+		close(r.chans[key])
+	}
+	for key := range r.routes {
+		r.routes[key].awaitShutdown()
+	}
+}
+
+// handleRoute sends data to the branch chosen by the router. Could only be the last step in the flo.
+func (s *stepRunner) handleRoute(ctx context.Context, input interface{}, send sendFn) {
+	key, err := s.call(stepContext(ctx), input)
+	if err != nil {
+		s.reportError(input, err)
+		return
+	}
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		// looking up an uncomparable key would panic, which is only possible when the key type is an interface
+		s.reportError(input, fmt.Errorf(routeKeyUncomparableFmt, key, key))
+		return
+	}
+	r := s.step.(*router)
+	ch, ok := r.chans[key]
+	if !ok {
+		ch, ok = r.chans[DefaultRoute]
+	}
+	if !ok {
+		atomic.AddInt64(&s.metrics.dropped, 1)
+		return
+	}
+	send(ctx, ch, input)
+}
+
+// merge is the Step registered by Builder.Merge. It combines the output of several producers into a single stream of
+// data.
+type merge struct {
+	producers []*Builder
+}
+
+// Merge registers a set of producers whose output is combined and fed into the next step. A producer may either be a
+// Step with a signature of func(context.Context) (R, error) or a Builder whose last step outputs data, for instance one
+// fed by its own input channel. Producers given as a Step are run with this Builder's options, such as its parallelism
+// and error handler. The output type of every producer must line up with the input type of the next step. A Merge must
+// be the first step registered with a Builder, and the steps that follow it will not shut down until every producer
+// has.
+//
+//  err := flo.NewBuilder().
+//             Merge(pollQueue, pollTopic, flo.NewBuilder(flo.WithInput(ch)).Add(parse)).
+//             Add(store).
+//             BuildAndExecute(ctx)
+func (b *Builder) Merge(producers ...Step) *Builder {
+	m := &merge{producers: make([]*Builder, len(producers))}
+	for i, p := range producers {
+		if pb, ok := p.(*Builder); ok {
+			m.producers[i] = pb
+			continue
+		}
+		options := []Option{
+			WithParallelism(b.parallelism),
+			WithOrdering(b.window),
+			WithErrorHandler(b.errHandler),
+			WithDeadLetterHandler(b.deadLetterHandler),
+			WithMiddleware(b.middleware...),
+		}
+		if b.repanic {
+			options = append(options, WithRepanic())
+		}
+		m.producers[i] = NewBuilder(options...).Add(p)
+	}
+	return b.Add(m)
+}
+
+// validate makes sure each producer outputs data and returns the types of data they output.
+func (m *merge) validate() ([]reflect.Type, error) {
+	if len(m.producers) == 0 {
+		return nil, errMergeCnt
+	}
+
+	var outputs []reflect.Type
+	for i, producer := range m.producers {
+		if producer == nil || len(producer.steps) == 0 {
+			return nil, fmt.Errorf("producer %d: %w", i+1, errBranchStepCnt)
+		}
+		out, err := producer.validate(nil, true)
+		if err != nil {
+			return nil, fmt.Errorf("producer %d: %w", i+1, err)
+		}
+		outputs = append(outputs, out...)
+	}
+	return outputs, nil
+}
+
+// start launches each producer and forwards their output to out.
+func (m *merge) start(ctx context.Context, wg *sync.WaitGroup, out chan interface{}) {
+	for _, producer := range m.producers {
+		producer.launch(ctx)
+		src := producer.steps[len(producer.steps)-1].output()
+		wg.Add(1)
+		go func() {
+			for {
+				v, ok := receive(ctx, src)
+				if !ok || !send(ctx, out, v) {
+					break
+				}
+			}
+			wg.Done()
+		}()
+	}
+}
+
+// awaitShutdown waits for each producer to finish processing.
+func (m *merge) awaitShutdown() {
+	for i := range m.producers {
+		m.producers[i].awaitShutdown()
+	}
+}
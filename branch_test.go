@@ -0,0 +1,324 @@
+package flo_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+func TestBroadcastValidateFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *flo.Builder
+	}{
+		{"no branches", flo.NewBuilder().Add(start).Broadcast()},
+		{"empty branch", flo.NewBuilder().Add(start).Broadcast(flo.NewBuilder())},
+		{"branch type mismatch", flo.NewBuilder().Add(start).Broadcast(flo.NewBuilder().Add(square))},
+		{"branch starts with source", flo.NewBuilder().Add(start).Broadcast(flo.NewBuilder().Add(start))},
+		{"branch with input chan", flo.NewBuilder().Add(start).Broadcast(flo.NewBuilder(flo.WithInput(make(chan string))).Add(end))},
+		{"broadcast not last", flo.NewBuilder().Add(start).Broadcast(flo.NewBuilder().Add(end)).Add(end)},
+		{"broadcast first", flo.NewBuilder().Broadcast(flo.NewBuilder().Add(end)).Add(end)},
+		{"bad nested branch", flo.NewBuilder().Add(start).Broadcast(
+			flo.NewBuilder().Add(middle).Broadcast(flo.NewBuilder().Add(square)),
+		)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestBroadcastValidate(t *testing.T) {
+	err := flo.NewBuilder().
+		Add(startStringThing).
+		Broadcast(
+			flo.NewBuilder().Add(endStringThing),
+			flo.NewBuilder().Add(middleStringThingToStringThing).Add(endStringThing),
+		).
+		Validate()
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestBroadcastBuildAndExecute(t *testing.T) {
+	inCh := make(chan string, 2)
+	inCh <- "a"
+	inCh <- "b"
+	close(inCh)
+	c1 := &collector{}
+	c2 := &collector{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh)).
+		Add(middle).
+		Broadcast(
+			flo.NewBuilder().Add(c1.collect),
+			flo.NewBuilder(flo.WithParallelism(3)).Add(middle).Add(exclaim).Add(c2.collect),
+		).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c1.sorted(); got != "A,B" {
+		t.Errorf("got %s, want A,B", got)
+	}
+	if got := c2.sorted(); got != "A!,B!" {
+		t.Errorf("got %s, want A!,B!", got)
+	}
+}
+
+func exclaim(ctx context.Context, s string) (string, error) {
+	return s + "!", nil
+}
+
+type collector struct {
+	sync.Mutex
+	items []string
+}
+
+func (c *collector) collect(ctx context.Context, s string) error {
+	c.Lock()
+	defer c.Unlock()
+	c.items = append(c.items, s)
+	return nil
+}
+
+func (c *collector) sorted() string {
+	c.Lock()
+	defer c.Unlock()
+	sort.Strings(c.items)
+	return strings.Join(c.items, ",")
+}
+
+func TestMergeValidateFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *flo.Builder
+	}{
+		{"no producers", flo.NewBuilder().Merge().Add(end)},
+		{"empty producer", flo.NewBuilder().Merge(flo.NewBuilder()).Add(end)},
+		{"bad producer step", flo.NewBuilder().Merge(start, end).Add(end)},
+		{"producer type mismatch", flo.NewBuilder().Merge(start, startInt).Add(end)},
+		{"producer ends in sink", flo.NewBuilder().Merge(start, flo.NewBuilder().Add(start).Add(end)).Add(end)},
+		{"producer with output chan", flo.NewBuilder().Merge(flo.NewBuilder(flo.WithOutput(make(chan string))).Add(start).Add(middle)).Add(end)},
+		{"merge not first", flo.NewBuilder().Add(start).Merge(start).Add(end)},
+		{"input chan type mismatch", flo.NewBuilder(flo.WithInput(make(chan string)), flo.WithInput(make(chan int))).Add(middle).Add(end)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestMergeValidateAssignable(t *testing.T) {
+	err := flo.NewBuilder().
+		Merge(startStringThing, flo.NewBuilder(flo.WithInput(make(chan fmt.Stringer))).Add(middleStringerToStringer)).
+		Add(endStringThing).
+		Validate()
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestMergeInputChannels(t *testing.T) {
+	inCh1 := make(chan string, 1)
+	inCh1 <- "a"
+	close(inCh1)
+	inCh2 := make(chan string, 1)
+	inCh2 <- "b"
+	close(inCh2)
+	c := &collector{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh1), flo.WithInput(inCh2)).
+		Add(middle).
+		Add(c.collect).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "A,B" {
+		t.Errorf("got %s, want A,B", got)
+	}
+}
+
+func TestMergeBuildAndExecute(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "b"
+	close(inCh)
+	c := &collector{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := flo.NewBuilder().
+		Merge(
+			func(ctx context.Context) (string, error) { return "a", nil },
+			flo.NewBuilder(flo.WithInput(inCh)).Add(middle),
+		).
+		Add(func(ctx context.Context, s string) error {
+			if s == "B" {
+				cancel()
+			}
+			return c.collect(ctx, s)
+		}).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); !strings.HasPrefix(got, "B") {
+		t.Errorf("got %s, want B to be collected", got)
+	}
+}
+
+func startInt(ctx context.Context) (int, error) {
+	return 1, nil
+}
+
+func TestRouteValidateFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *flo.Builder
+	}{
+		{"no routes", flo.NewBuilder().Add(start).Route(byLength, nil)},
+		{"bad router", flo.NewBuilder().Add(start).Route(end, map[interface{}]*flo.Builder{1: flo.NewBuilder().Add(end)})},
+		{"uncomparable key", flo.NewBuilder().Add(start).Route(
+			func(ctx context.Context, s string) ([]int, error) { return nil, nil },
+			map[interface{}]*flo.Builder{1: flo.NewBuilder().Add(end)},
+		)},
+		{"router type mismatch", flo.NewBuilder().Add(startInt).Route(byLength, map[interface{}]*flo.Builder{1: flo.NewBuilder().Add(end)})},
+		{"key type mismatch", flo.NewBuilder().Add(start).Route(byLength, map[interface{}]*flo.Builder{"1": flo.NewBuilder().Add(end)})},
+		{"branch type mismatch", flo.NewBuilder().Add(start).Route(byLength, map[interface{}]*flo.Builder{1: flo.NewBuilder().Add(square)})},
+		{"default branch type mismatch", flo.NewBuilder().Add(start).Route(byLength, map[interface{}]*flo.Builder{
+			1:                flo.NewBuilder().Add(end),
+			flo.DefaultRoute: flo.NewBuilder().Add(square),
+		})},
+		{"empty branch", flo.NewBuilder().Add(start).Route(byLength, map[interface{}]*flo.Builder{1: flo.NewBuilder()})},
+		{"route not last", flo.NewBuilder().Add(start).Route(byLength, map[interface{}]*flo.Builder{1: flo.NewBuilder().Add(end)}).Add(end)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestRouteBuildAndExecute(t *testing.T) {
+	inCh := make(chan string, 4)
+	inCh <- "a"
+	inCh <- "bb"
+	inCh <- "cc"
+	inCh <- "ddd"
+	close(inCh)
+	short := &collector{}
+	long := &collector{}
+	eh := &errHandle{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh)).
+		Add(middle).
+		Route(byLength, map[interface{}]*flo.Builder{
+			1:                flo.NewBuilder().Add(short.collect),
+			flo.DefaultRoute: flo.NewBuilder(flo.WithParallelism(2)).Add(long.collect),
+		}, flo.WithStepParallelism(2), flo.WithStepErrorHandler(eh.handleError)).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := short.sorted(); got != "A" {
+		t.Errorf("got %s, want A", got)
+	}
+	if got := long.sorted(); got != "BB,CC" {
+		t.Errorf("got %s, want BB,CC", got)
+	}
+	if !eh.hasHandled {
+		t.Errorf("got false, want true")
+	}
+}
+
+func TestRouteDeadEnd(t *testing.T) {
+	inCh := make(chan string, 2)
+	inCh <- "a"
+	inCh <- "bb"
+	close(inCh)
+	c := &collector{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh)).
+		Add(middle).
+		Route(byLength, map[interface{}]*flo.Builder{2: flo.NewBuilder().Add(c.collect)}).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "BB" {
+		t.Errorf("got %s, want BB", got)
+	}
+}
+
+func TestRouteUncomparableKey(t *testing.T) {
+	inCh := make(chan string, 2)
+	inCh <- "a"
+	inCh <- "bb"
+	close(inCh)
+	c := &collector{}
+	eh := &errHandle{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh)).
+		Add(middle).
+		Route(func(ctx context.Context, s string) (interface{}, error) {
+			if len(s) > 1 {
+				return []int{len(s)}, nil
+			}
+			return len(s), nil
+		}, map[interface{}]*flo.Builder{1: flo.NewBuilder().Add(c.collect)}, flo.WithStepErrorHandler(eh.handleError)).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "A" {
+		t.Errorf("got %s, want A", got)
+	}
+	if !eh.hasHandled {
+		t.Errorf("got false, want true")
+	}
+}
+
+func TestRouteValidateIsStable(t *testing.T) {
+	b := flo.NewBuilder().Add(start).Route(byLength, map[interface{}]*flo.Builder{
+		1: flo.NewBuilder().Add(square),
+		2: flo.NewBuilder().Add(square),
+		3: flo.NewBuilder(),
+	})
+	want := b.Validate()
+	if want == nil {
+		t.Fatalf("got nil, want error")
+	}
+	for i := 0; i < 20; i++ {
+		if got := b.Validate(); got.Error() != want.Error() {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// byLength routes strings by their length, erroring on anything longer than two characters.
+func byLength(ctx context.Context, s string) (int, error) {
+	if len(s) > 2 {
+		return 0, fmt.Errorf("too long")
+	}
+	return len(s), nil
+}
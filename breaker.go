@@ -0,0 +1,202 @@
+package flo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is reported when a step's circuit breaker is open and the step was not called. Use errors.Is to check
+// for it.
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed means the step is called as usual.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen means the step has failed too many times in a row and is not called until the cool down passes.
+	BreakerOpen
+	// BreakerHalfOpen means the cool down has passed and a single trial call is being made to the step. Should it
+	// succeed the breaker closes, otherwise it opens again.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerPolicy configures a step's circuit breaker.
+type BreakerPolicy struct {
+	// Threshold is how many times in a row the step must fail for the breaker to open. Defaults to 5.
+	Threshold int
+	// CoolDown is how long the breaker stays open before a trial call is made to the step. Defaults to 30s.
+	CoolDown time.Duration
+	// Hold configures the breaker to hold on to data while it is open, until the step may be called again, rather than
+	// failing fast with ErrBreakerOpen.
+	Hold bool
+	// OnStateChange, if set, is called each time the breaker changes state. It is called synchronously, so it should
+	// not block.
+	OnStateChange func(from, to BreakerState)
+}
+
+// WithStepBreaker configures a circuit breaker for a step. Once the step fails Threshold times in a row the breaker
+// opens, and rather than calling the step ErrBreakerOpen is reported to the error handler until the cool down passes.
+// When combined with WithStepRetry only the outcome of the final attempt counts towards the breaker. A source has no
+// data to fail fast on, so it always waits out the cool down as if Hold was set. Circuit breakers have no effect on
+// batches.
+//
+//  err := flo.NewBuilder(flo.WithInput(ch)).
+//             Add(charge, flo.WithStepBreaker(flo.BreakerPolicy{Threshold: 3, CoolDown: time.Minute})).
+//             Add(receipt).
+//             BuildAndExecute(ctx)
+func WithStepBreaker(policy BreakerPolicy) StepOption {
+	return func(s *stepRunner) {
+		if policy.Threshold < 1 {
+			policy.Threshold = 5
+		}
+		if policy.CoolDown <= 0 {
+			policy.CoolDown = 30 * time.Second
+		}
+		s.breaker = &breaker{policy: policy, changed: make(chan struct{})}
+	}
+}
+
+// breaker tracks the state of a step's circuit breaker.
+type breaker struct {
+	policy BreakerPolicy
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	// trial is true while a half-open breaker is waiting on its trial call.
+	trial bool
+	// changed is closed, and replaced, every time the breaker changes state.
+	changed chan struct{}
+}
+
+// wrap returns a callFn that only calls fn when the breaker allows it, recording the outcome of each call. If hold is
+// set, calls wait for the breaker to allow them even if the policy does not hold data.
+func (br *breaker) wrap(fn callFn, hold bool) callFn {
+	hold = hold || br.policy.Hold
+	return func(ctx context.Context, in interface{}) (interface{}, error) {
+		if err := br.acquire(ctx, hold); err != nil {
+			return nil, err
+		}
+		value, err := fn(ctx, in)
+		br.record(err)
+		return value, err
+	}
+}
+
+// acquire returns nil once the step may be called. Unless hold is set, it fails fast with ErrBreakerOpen.
+func (br *breaker) acquire(ctx context.Context, hold bool) error {
+	for {
+		br.mu.Lock()
+		allowed, wait, changed := br.allow()
+		br.mu.Unlock()
+		if allowed {
+			return nil
+		}
+		if !hold {
+			return ErrBreakerOpen
+		}
+
+		if err := waitForChange(ctx, changed, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForChange blocks until changed is closed, wait has passed or the ctx is done. A wait of zero waits indefinitely.
+func waitForChange(ctx context.Context, changed chan struct{}, wait time.Duration) error {
+	var timeout <-chan time.Time
+	if wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-changed:
+	case <-timeout:
+	}
+	return nil
+}
+
+// allow reports whether the step may be called. If not, it returns how long until the breaker's cool down passes and a
+// channel that is closed when the breaker next changes state. It must be called with mu held.
+func (br *breaker) allow() (bool, time.Duration, chan struct{}) {
+	switch br.state {
+	case BreakerOpen:
+		wait := br.policy.CoolDown - time.Since(br.openedAt)
+		if wait > 0 {
+			return false, wait, br.changed
+		}
+		br.setState(BreakerHalfOpen)
+		br.trial = true
+		return true, 0, nil
+	case BreakerHalfOpen:
+		if br.trial {
+			return false, 0, br.changed
+		}
+		br.trial = true
+		return true, 0, nil
+	}
+	return true, 0, nil
+}
+
+// record updates the state of the breaker based on the outcome of a call to the step.
+func (br *breaker) record(err error) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	switch br.state {
+	case BreakerClosed:
+		if err == nil {
+			br.failures = 0
+			return
+		}
+		br.failures++
+		if br.failures >= br.policy.Threshold {
+			br.open()
+		}
+	case BreakerHalfOpen:
+		br.trial = false
+		if err == nil {
+			br.failures = 0
+			br.setState(BreakerClosed)
+			return
+		}
+		br.open()
+	}
+}
+
+// open opens the breaker, starting its cool down. It must be called with mu held.
+func (br *breaker) open() {
+	br.openedAt = time.Now()
+	br.setState(BreakerOpen)
+}
+
+// setState moves the breaker into a new state, waking up any held data. It must be called with mu held.
+func (br *breaker) setState(state BreakerState) {
+	from := br.state
+	br.state = state
+	close(br.changed)
+	br.changed = make(chan struct{})
+	if br.policy.OnStateChange != nil {
+		br.policy.OnStateChange(from, state)
+	}
+}
@@ -0,0 +1,117 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestStepBreakerFailsFast(t *testing.T) {
+	inCh := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		inCh <- "a"
+	}
+	close(inCh)
+	var calls int32
+	var errs []error
+	var changes []string
+	var mu sync.Mutex
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(1)).
+		Add(func(ctx context.Context, s string) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			return "", errFlaky
+		}, flo.WithStepBreaker(flo.BreakerPolicy{
+			Threshold: 2,
+			CoolDown:  time.Hour,
+			OnStateChange: func(from, to flo.BreakerState) {
+				changes = append(changes, from.String()+" to "+to.String())
+			},
+		}), flo.WithStepErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		})).
+		Add(end).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("got %d calls, want 2", got)
+	}
+	var open int
+	for _, err := range errs {
+		if errors.Is(err, flo.ErrBreakerOpen) {
+			open++
+		}
+	}
+	if len(errs) != 5 || open != 3 {
+		t.Errorf("got %d errors with %d open, want 5 with 3 open", len(errs), open)
+	}
+	if len(changes) != 1 || changes[0] != "closed to open" {
+		t.Errorf("got %v, want [closed to open]", changes)
+	}
+}
+
+func TestStepBreakerHold(t *testing.T) {
+	inCh := make(chan string, 3)
+	inCh <- "a"
+	inCh <- "b"
+	inCh <- "c"
+	close(inCh)
+	c := &collector{}
+	eh := &errHandle{}
+	var calls int32
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(1)).
+		Add(func(ctx context.Context, s string) (string, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return "", errFlaky
+			}
+			return middle(ctx, s)
+		}, flo.WithStepBreaker(flo.BreakerPolicy{Threshold: 1, CoolDown: 10 * time.Millisecond, Hold: true}),
+			flo.WithStepErrorHandler(eh.handleError)).
+		Add(c.collect).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "B,C" {
+		t.Errorf("got %s, want B,C", got)
+	}
+	if !eh.hasHandled {
+		t.Errorf("got false, want true")
+	}
+}
+
+func TestStepBreakerSourceWaitsOutCoolDown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls, errs int32
+
+	err := flo.NewBuilder(flo.WithParallelism(1)).
+		Add(func(ctx context.Context) (string, error) {
+			if atomic.AddInt32(&calls, 1) == 3 {
+				cancel()
+			}
+			return "", errFlaky
+		}, flo.WithStepBreaker(flo.BreakerPolicy{Threshold: 1, CoolDown: 20 * time.Millisecond}),
+			flo.WithStepErrorHandler(func(err error) { atomic.AddInt32(&errs, 1) })).
+		Add(end).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// every error comes from a call to the source, rather than from the breaker rejecting calls while it is open
+	if c, e := atomic.LoadInt32(&calls), atomic.LoadInt32(&errs); c != 3 || e != 3 {
+		t.Errorf("got %d calls and %d errors, want 3 and 3", c, e)
+	}
+}
@@ -0,0 +1,40 @@
+package flo
+
+import "time"
+
+// DeadLetter describes a piece of data that a step failed to process.
+type DeadLetter struct {
+	// Input is the data the step was processing when it failed. It is nil for steps that do not take in data.
+	Input interface{}
+	// Step is the position, starting at one, of the step within the Builder it was registered with.
+	Step int
+	// Name is the name of the step, see WithStepName.
+	Name string
+	// Err is the error returned by the step, wrapped in a StepError.
+	Err error
+	// Time is when the step failed.
+	Time time.Time
+}
+
+// DeadLetterHandler is a function that takes a DeadLetter. It allows the user to persist data that failed to be
+// processed, so it may be processed again later.
+type DeadLetterHandler func(DeadLetter)
+
+// WithDeadLetterHandler configures the default dead letter handler for when a Step returns an error. It is called in
+// addition to the error handler. Should you want to deliver dead letters to a channel, the handler can send them along:
+//
+//  deadLetters := make(chan flo.DeadLetter, 100)
+//  b := flo.NewBuilder(flo.WithDeadLetterHandler(func(dl flo.DeadLetter) { deadLetters <- dl }))
+func WithDeadLetterHandler(handler DeadLetterHandler) Option {
+	return func(b *Builder) {
+		b.deadLetterHandler = handler
+	}
+}
+
+// WithStepDeadLetterHandler configures a dead letter handler for when a Step returns an error, see
+// WithDeadLetterHandler for details.
+func WithStepDeadLetterHandler(handler DeadLetterHandler) StepOption {
+	return func(s *stepRunner) {
+		s.deadLetterHandler = handler
+	}
+}
@@ -0,0 +1,122 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+var errDead = errors.New("dead")
+
+type deadLetters struct {
+	sync.Mutex
+	letters []flo.DeadLetter
+}
+
+func (d *deadLetters) handle(dl flo.DeadLetter) {
+	d.Lock()
+	defer d.Unlock()
+	d.letters = append(d.letters, dl)
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		b     func(options ...flo.Option) *flo.Builder
+		step  int
+		input string
+	}{
+		{"in out", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).
+				Add(middle).
+				Add(func(ctx context.Context, s string) (string, error) { return "", errDead }).
+				Add(end)
+		}, 2, "A"},
+		{"only in", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).
+				Add(middle).
+				Add(func(ctx context.Context, s string) error { return errDead })
+		}, 2, "A"},
+		{"emitter", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).
+				Add(func(ctx context.Context, s string, emit func(string) error) error { return errDead }).
+				Add(end)
+		}, 1, "a"},
+		{"filter", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).
+				Filter(func(ctx context.Context, s string) (bool, error) { return false, errDead }).
+				Add(end)
+		}, 1, "a"},
+		{"flat map", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).
+				FlatMap(func(ctx context.Context, s string) ([]string, error) { return nil, errDead }).
+				Add(end)
+		}, 1, "a"},
+		{"route", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).
+				Add(middle).
+				Route(func(ctx context.Context, s string) (int, error) { return 0, errDead },
+					map[interface{}]*flo.Builder{flo.DefaultRoute: flo.NewBuilder().Add(end)})
+		}, 2, "A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inCh := make(chan string, 1)
+			inCh <- "a"
+			close(inCh)
+			dl := &deadLetters{}
+
+			err := tt.b(flo.WithInput(inCh), flo.WithDeadLetterHandler(dl.handle)).BuildAndExecute(context.Background())
+			if err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+
+			if len(dl.letters) != 1 {
+				t.Fatalf("got %d dead letters, want 1", len(dl.letters))
+			}
+			got := dl.letters[0]
+			if got.Input != tt.input {
+				t.Errorf("got input %v, want %s", got.Input, tt.input)
+			}
+			if got.Step != tt.step {
+				t.Errorf("got step %d, want %d", got.Step, tt.step)
+			}
+			if !errors.Is(got.Err, errDead) {
+				t.Errorf("got %v, want %v", got.Err, errDead)
+			}
+			if got.Time.IsZero() {
+				t.Errorf("got zero time, want non-zero")
+			}
+		})
+	}
+}
+
+func TestStepDeadLetterHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	dl := &deadLetters{}
+	stepDl := &deadLetters{}
+
+	err := flo.NewBuilder(flo.WithDeadLetterHandler(dl.handle)).
+		Add(func(ctx context.Context) (string, error) {
+			cancel()
+			return "", errDead
+		}, flo.WithStepDeadLetterHandler(stepDl.handle), flo.WithStepParallelism(1)).
+		Add(end).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(dl.letters) != 0 {
+		t.Errorf("got %d dead letters, want 0", len(dl.letters))
+	}
+	if len(stepDl.letters) != 1 {
+		t.Fatalf("got %d dead letters, want 1", len(stepDl.letters))
+	}
+	if got := stepDl.letters[0]; got.Input != nil || got.Step != 1 {
+		t.Errorf("got input %v and step %d, want nil and 1", got.Input, got.Step)
+	}
+}
@@ -0,0 +1,69 @@
+package flo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// StepError is the error passed to error and dead letter handlers when a step fails. It describes which step failed
+// and on what data. Use errors.Is and errors.As to inspect the error the step returned.
+type StepError struct {
+	// Step is the position, starting at one, of the step within the Builder it was registered with.
+	Step int
+	// Name is the name of the step, see WithStepName.
+	Name string
+	// Func is the name of the step's function, as reported by runtime.FuncForPC.
+	Func string
+	// Input is the data the step failed to process. It is nil for steps that do not take in data.
+	Input interface{}
+	// Attempt is the attempt, starting at one, on which the step failed. It is zero if the error did not come from a
+	// call to the step, such as when its circuit breaker was open.
+	Attempt int
+	// Err is the error returned by the step.
+	Err error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %d (%s): %v", e.Step, e.Name, e.Err)
+}
+
+// Unwrap returns the error returned by the step.
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// stepErrorCall returns a callFn that wraps any error returned by fn in a StepError.
+func (s *stepRunner) stepErrorCall(fn callFn) callFn {
+	return func(ctx context.Context, in interface{}) (interface{}, error) {
+		value, err := fn(ctx, in)
+		if err != nil {
+			err = s.stepError(in, Attempt(ctx), err)
+		}
+		return value, err
+	}
+}
+
+// stepError wraps err in a StepError, unless it has already been wrapped in one for this step. A StepError from another
+// step, such as one returned by a nested flo, is wrapped like any other error.
+func (s *stepRunner) stepError(input interface{}, attempt int, err error) error {
+	var se *StepError
+	if errors.As(err, &se) && se.Step == s.index && se.Name == s.name {
+		return err
+	}
+	return &StepError{Step: s.index, Name: s.name, Func: s.funcName, Input: input, Attempt: attempt, Err: err}
+}
+
+// funcName returns the name of the function fn, or an empty string if it is not a function.
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return ""
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestStepError(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "test"
+	close(inCh)
+	var errs []error
+	var mu sync.Mutex
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	})).
+		Add(middle).
+		Add(erroringMiddle, flo.WithStepRetry(flo.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})).
+		Add(end).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+	var se *flo.StepError
+	if !errors.As(errs[0], &se) {
+		t.Fatalf("got %T, want *flo.StepError", errs[0])
+	}
+	if se.Step != 2 || se.Input != "TEST" || se.Attempt != 2 {
+		t.Errorf("got step %d, input %v and attempt %d, want 2, TEST and 2", se.Step, se.Input, se.Attempt)
+	}
+	if !strings.HasSuffix(se.Func, "erroringMiddle") {
+		t.Errorf("got %s, want it to end with erroringMiddle", se.Func)
+	}
+	if se.Unwrap() == nil || errors.Unwrap(errs[0]) != se.Err {
+		t.Errorf("got %v, want the step's error", errors.Unwrap(errs[0]))
+	}
+	if got := se.Error(); !strings.HasPrefix(got, "step 2 (") || !strings.HasSuffix(got, se.Err.Error()) {
+		t.Errorf("got %s, want step 2 (...): %v", got, se.Err)
+	}
+}
+
+func TestStepErrorNotCalled(t *testing.T) {
+	inCh := make(chan string, 2)
+	inCh <- "a"
+	inCh <- "b"
+	close(inCh)
+	var errs []error
+	var mu sync.Mutex
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(1)).
+		Filter(func(ctx context.Context, s string) (bool, error) {
+			return false, errFlaky
+		}, flo.WithStepBreaker(flo.BreakerPolicy{Threshold: 1, CoolDown: time.Hour}), flo.WithStepErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		})).
+		Add(end).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errs))
+	}
+	var se *flo.StepError
+	if !errors.As(errs[1], &se) || !errors.Is(se, flo.ErrBreakerOpen) {
+		t.Fatalf("got %v, want a *flo.StepError wrapping %v", errs[1], flo.ErrBreakerOpen)
+	}
+	if se.Attempt != 0 || se.Input != "b" || !strings.Contains(se.Func, "TestStepErrorNotCalled") {
+		t.Errorf("got attempt %d, input %v and func %s, want 0, b and the filter", se.Attempt, se.Input, se.Func)
+	}
+}
+
+func TestStepErrorWrapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     flo.Step
+		options  []flo.StepOption
+		wantName string
+		layers   int
+	}{
+		{"wrapped by middleware", erroringMiddle, []flo.StepOption{flo.WithStepMiddleware(
+			func(ctx context.Context, info flo.StepInfo, input interface{}, next flo.Handler) (interface{}, error) {
+				v, err := next(ctx, input)
+				if err != nil {
+					err = fmt.Errorf("middleware: %w", err)
+				}
+				return v, err
+			})}, "erroringMiddle", 1},
+		{"from another flo", func(ctx context.Context, s string) (string, error) {
+			return "", &flo.StepError{Step: 1, Name: "inner", Err: errDead}
+		}, []flo.StepOption{flo.WithStepName("outer")}, "outer", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inCh := make(chan string, 1)
+			inCh <- "a"
+			close(inCh)
+			var got error
+
+			options := append(tt.options, flo.WithStepErrorHandler(func(err error) { got = err }))
+			err := flo.NewBuilder(flo.WithInput(inCh)).
+				Add(middle).
+				Add(tt.step, options...).
+				Add(end).
+				BuildAndExecute(context.Background())
+			if err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+
+			var se *flo.StepError
+			if !errors.As(got, &se) {
+				t.Fatalf("got %T, want *flo.StepError", got)
+			}
+			if se.Step != 2 || se.Name != tt.wantName {
+				t.Errorf("got step %d (%s), want step 2 (%s)", se.Step, se.Name, tt.wantName)
+			}
+			if n := strings.Count(got.Error(), "step "); n != tt.layers {
+				t.Errorf("got %q, want %d StepErrors", got, tt.layers)
+			}
+		})
+	}
+}
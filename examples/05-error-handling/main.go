@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/codyoss/flo"
@@ -23,16 +24,20 @@ func main() {
 		BuildAndExecute(context.Background())
 	// Output:
 	// Handler error at a Global level: Oh no, I failed on the first step
-	// Handler error at a Step level: Oh no, I failed on the last step
+	// Handler error at a Step level: step 2 failed on "Another Message": Oh no, I failed on the last step
 }
 
 // A function that handles an error
 func globalErrorHandler(err error) {
-	fmt.Printf("Handler error at a Global level: %v\n", err)
+	fmt.Printf("Handler error at a Global level: %v\n", errors.Unwrap(err))
 }
 
+// Errors returned by steps are wrapped in a flo.StepError, which describes the step that failed and its input.
 func stepErrorHandler(err error) {
-	fmt.Printf("Handler error at a Step level: %v\n", err)
+	var se *flo.StepError
+	if errors.As(err, &se) {
+		fmt.Printf("Handler error at a Step level: step %d failed on %q: %v\n", se.Step, se.Input, se.Err)
+	}
 }
 
 type errorStep int
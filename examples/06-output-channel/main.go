@@ -13,18 +13,19 @@ func main() {
 	inputChannel <- "Another message"
 	close(inputChannel)
 	// make an output channel to receive data from the flo
-	outputChannel := make(chan string, 1)
+	outputChannel := make(chan string)
 
-	// Register the output channel
-	flo.NewBuilder(flo.WithInput(inputChannel), flo.WithOutput(outputChannel)).
-		Add(exclaim).
-		Add(exclaim).
-		BuildAndExecute(context.Background())
+	// Register the output channel, and have the flo close it once it shuts down so it can be ranged over.
+	go func() {
+		flo.NewBuilder(flo.WithInput(inputChannel), flo.WithOutput(outputChannel), flo.WithCloseOutput()).
+			Add(exclaim).
+			Add(exclaim).
+			BuildAndExecute(context.Background())
+	}()
 
-	fmt.Println(<-outputChannel)
-	fmt.Println(<-outputChannel)
-	// It is your responsibility to close this channel once the flo shutsdown
-	close(outputChannel)
+	for msg := range outputChannel {
+		fmt.Println(msg)
+	}
 	// Output:
 	// Hello World!!!
 	// Another message!!!
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codyoss/flo"
+)
+
+func main() {
+	inputChannel := make(chan string, 2)
+	inputChannel <- "Hello World"
+	inputChannel <- "Another message"
+	close(inputChannel)
+
+	// Input, Then and End check that each step lines up with the previous one at compile time. Swapping the order of
+	// count and shout below would not compile.
+	c := flo.Input(flo.NewBuilder(), inputChannel)
+	s := flo.Then(c, shout)
+	l := flo.Then(s, count, flo.WithStepParallelism(2)) // StepOptions work just like they do with Add
+	flo.End(l, print).
+		BuildAndExecute(context.Background())
+	// Output:
+	// 11
+	// 15
+}
+
+func shout(ctx context.Context, msg string) (string, error) {
+	return strings.ToUpper(msg), nil
+}
+
+func count(ctx context.Context, msg string) (int, error) {
+	return len(msg), nil
+}
+
+func print(ctx context.Context, i int) error {
+	fmt.Println(i)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codyoss/flo"
+)
+
+func main() {
+	inputChannel := make(chan string, 1)
+	inputChannel <- "Hello World"
+	close(inputChannel)
+
+	// Each branch is its own flo that is fed every output of the step before the broadcast. Branches are configured
+	// independently, so each one may have its own parallelism and error handling.
+	archive := flo.NewBuilder().
+		Add(lower).
+		Add(print("archived"))
+	index := flo.NewBuilder(flo.WithParallelism(3)).
+		Add(print("indexed"))
+
+	flo.NewBuilder(flo.WithInput(inputChannel)).
+		Add(exclaim).
+		Broadcast(archive, index).
+		BuildAndExecute(context.Background())
+	// Output(in any order):
+	// archived: hello world!
+	// indexed: Hello World!
+}
+
+func exclaim(ctx context.Context, msg string) (string, error) {
+	return msg + "!", nil
+}
+
+func lower(ctx context.Context, msg string) (string, error) {
+	return strings.ToLower(msg), nil
+}
+
+func print(prefix string) func(context.Context, string) error {
+	return func(ctx context.Context, msg string) error {
+		fmt.Printf("%s: %s\n", prefix, msg)
+		return nil
+	}
+}
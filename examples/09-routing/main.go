@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codyoss/flo"
+)
+
+func main() {
+	inputChannel := make(chan string, 3)
+	inputChannel <- "ERROR disk full"
+	inputChannel <- "INFO started"
+	inputChannel <- "DEBUG tick"
+	close(inputChannel)
+
+	// The router picks which branch each message is sent to. Each branch is its own flo, so each one may be tuned
+	// independently. Messages that do not match a route go to the DefaultRoute, or are dropped if there is none.
+	flo.NewBuilder(flo.WithInput(inputChannel)).
+		Add(trim).
+		Route(level, map[interface{}]*flo.Builder{
+			"ERROR":          flo.NewBuilder(flo.WithParallelism(3)).Add(print("paging")),
+			"INFO":           flo.NewBuilder().Add(print("logging")),
+			flo.DefaultRoute: flo.NewBuilder().Add(print("ignoring")),
+		}).
+		BuildAndExecute(context.Background())
+	// Output(in any order):
+	// paging: ERROR disk full
+	// logging: INFO started
+	// ignoring: DEBUG tick
+}
+
+func trim(ctx context.Context, msg string) (string, error) {
+	return strings.TrimSpace(msg), nil
+}
+
+func level(ctx context.Context, msg string) (string, error) {
+	return strings.SplitN(msg, " ", 2)[0], nil
+}
+
+func print(prefix string) func(context.Context, string) error {
+	return func(ctx context.Context, msg string) error {
+		fmt.Printf("%s: %s\n", prefix, msg)
+		return nil
+	}
+}
@@ -0,0 +1,121 @@
+package flo
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithName configures the name of the flo. The name is used to label the flo's metrics.
+func WithName(name string) Option {
+	return func(b *Builder) {
+		b.name = name
+	}
+}
+
+// MetricsHandler returns an http.Handler that serves the stats of each of the flos in the Prometheus text exposition
+// format. Each series is labeled with the name of its flo, along with the position and name of its step. Steps within
+// a branch are labeled with the path to them, for instance a step labeled "3/default/1" is the first step of the
+// default route of the flo's third step.
+//
+//  b := flo.NewBuilder(flo.WithName("orders"), flo.WithInput(ch))
+//  http.Handle("/metrics", flo.MetricsHandler(b))
+func MetricsHandler(flos ...*Builder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var series []stepSeries
+		for _, b := range flos {
+			series = appendSeries(series, b.name, "", b.Stats())
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, series)
+	})
+}
+
+// PublishExpvar publishes the flo's stats with the expvar package, under the name "flo" or "flo.<name>" if the flo was
+// configured WithName. Like expvar.Publish, it panics if the name is already in use.
+func (b *Builder) PublishExpvar() {
+	name := "flo"
+	if b.name != "" {
+		name += "." + b.name
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return b.Stats()
+	}))
+}
+
+// stepSeries are the labels and stats of a single step.
+type stepSeries struct {
+	flo   string
+	step  string
+	stats StepStats
+}
+
+// appendSeries adds the stats of each step of a flo, and those of its branches, to series.
+func appendSeries(series []stepSeries, flo, prefix string, stats Stats) []stepSeries {
+	for _, st := range stats.Steps {
+		step := prefix + strconv.Itoa(st.Step)
+		series = append(series, stepSeries{flo: flo, step: step, stats: st})
+
+		keys := make([]string, 0, len(st.Branches))
+		for key := range st.Branches {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			series = appendSeries(series, flo, step+"/"+key+"/", st.Branches[key])
+		}
+	}
+	return series
+}
+
+// writeMetrics writes each metric family for the given series in the Prometheus text exposition format.
+func writeMetrics(w io.Writer, series []stepSeries) {
+	families := []struct {
+		name, help, kind string
+		value            func(StepStats) int64
+	}{
+		{"flo_step_in_total", "Pieces of data taken in by the step.", "counter", func(st StepStats) int64 { return st.In }},
+		{"flo_step_out_total", "Pieces of data sent along by the step.", "counter", func(st StepStats) int64 { return st.Out }},
+		{"flo_step_errors_total", "Errors returned by the step.", "counter", func(st StepStats) int64 { return st.Errored }},
+		{"flo_step_dropped_total", "Pieces of data dropped by the step.", "counter", func(st StepStats) int64 { return st.Dropped }},
+		{"flo_step_queue_depth", "Pieces of data waiting on the next step.", "gauge", func(st StepStats) int64 { return int64(st.QueueDepth) }},
+		{"flo_step_queue_capacity", "Pieces of data that may wait on the next step.", "gauge", func(st StepStats) int64 { return int64(st.QueueCapacity) }},
+		{"flo_step_workers", "Workers configured for the step.", "gauge", func(st StepStats) int64 { return int64(st.Workers) }},
+		{"flo_step_active_workers", "Workers currently processing data.", "gauge", func(st StepStats) int64 { return st.ActiveWorkers }},
+	}
+	for _, f := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, s := range series {
+			fmt.Fprintf(w, "%s{%s} %d\n", f.name, s.labels(), f.value(s.stats))
+		}
+	}
+
+	const latency = "flo_step_latency_seconds"
+	fmt.Fprintf(w, "# HELP %s How long calls to the step took.\n# TYPE %s histogram\n", latency, latency)
+	for _, s := range series {
+		labels := s.labels()
+		for _, bucket := range s.stats.Latency.Buckets {
+			le := strconv.FormatFloat(bucket.UpperBound.Seconds(), 'g', -1, 64)
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", latency, labels, le, bucket.Count)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", latency, labels, s.stats.Latency.Count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", latency, labels, strconv.FormatFloat(s.stats.Latency.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", latency, labels, s.stats.Latency.Count)
+	}
+}
+
+// labels formats the labels of a series.
+func (s stepSeries) labels() string {
+	return fmt.Sprintf(`flo="%s",step="%s",name="%s"`, escapeLabel(s.flo), escapeLabel(s.step), escapeLabel(s.stats.Name))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value as required by the Prometheus text exposition format.
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
@@ -0,0 +1,75 @@
+package flo_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	inCh := make(chan string, 2)
+	inCh <- "a"
+	inCh <- "b"
+	close(inCh)
+	b := flo.NewBuilder(flo.WithName(`or"ders`), flo.WithInput(inCh)).
+		Add(middle).
+		Broadcast(flo.NewBuilder().Add(end))
+	if err := b.BuildAndExecute(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	other := flo.NewBuilder().Add(start).Add(end)
+
+	rec := httptest.NewRecorder()
+	flo.MetricsHandler(b, other).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Result().Body)
+	got := string(body)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %s, want text/plain; version=0.0.4", ct)
+	}
+	for _, want := range []string{
+		"# TYPE flo_step_in_total counter\n",
+		`flo_step_in_total{flo="or\"ders",step="1",name="middle"} 2` + "\n",
+		`flo_step_out_total{flo="or\"ders",step="2",name="broadcast"} 2` + "\n",
+		`flo_step_in_total{flo="or\"ders",step="2/1/1",name="end"} 2` + "\n",
+		`flo_step_in_total{flo="",step="1",name="start"} 0` + "\n",
+		"# TYPE flo_step_latency_seconds histogram\n",
+		`flo_step_latency_seconds_bucket{flo="or\"ders",step="1",name="middle",le="0.001"} `,
+		`flo_step_latency_seconds_bucket{flo="or\"ders",step="1",name="middle",le="+Inf"} 2` + "\n",
+		`flo_step_latency_seconds_count{flo="or\"ders",step="1",name="middle"} 2` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got:\n%s\nwant it to contain %q", got, want)
+		}
+	}
+	if strings.Count(got, "# TYPE flo_step_in_total") != 1 {
+		t.Errorf("got:\n%s\nwant each metric family to be written once", got)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	// expvar names may only be published once per process, so each run of the test needs its own
+	name := fmt.Sprintf("expvar-test-%d", time.Now().UnixNano())
+	b := flo.NewBuilder(flo.WithName(name)).Add(start).Add(end)
+	b.PublishExpvar()
+
+	v := expvar.Get("flo." + name)
+	if v == nil {
+		t.Fatal("got nil, want the flo's stats to be published")
+	}
+	var stats flo.Stats
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(stats.Steps) != 2 {
+		t.Errorf("got %d steps, want 2", len(stats.Steps))
+	}
+}
@@ -0,0 +1,70 @@
+package flo
+
+import (
+	"context"
+	"sync"
+)
+
+// WithFailFast configures the flo to stop as soon as any step returns an error. The flo's context is canceled, aborting
+// the flo, and the error is returned from BuildAndExecute, much like an errgroup. Errors are still passed to any error
+// and dead letter handlers. This applies to the whole flo, including its branches, and has no effect on a Builder used
+// as a branch or producer.
+func WithFailFast() Option {
+	return WithFailFastOn(func(error) bool { return true })
+}
+
+// WithFailFastOn configures the flo to stop as soon as a step returns an error that match reports true for, see
+// WithFailFast for details. Other errors are handled as usual.
+//
+//  err := flo.NewBuilder(flo.WithInput(ch), flo.WithFailFastOn(func(err error) bool {
+//             return errors.Is(err, sql.ErrConnDone)
+//         })).
+//             Add(load).
+//             Add(store).
+//             BuildAndExecute(ctx)
+func WithFailFastOn(match func(error) bool) Option {
+	return func(b *Builder) {
+		b.failFast = match
+	}
+}
+
+type failerKey struct{}
+
+// failer records the first error that stops a fail fast flo.
+type failer struct {
+	match  func(error) bool
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	first error
+}
+
+// withFailer returns a context that carries a failer, which cancels the context once a matching error is recorded.
+// Steps pick up the failer from the context they are started with, so it reaches every branch and producer.
+func withFailer(ctx context.Context, match func(error) bool) (context.Context, *failer) {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &failer{match: match, ctx: ctx, cancel: cancel}
+	return context.WithValue(ctx, failerKey{}, f), f
+}
+
+// record stops the flo if err is the first error that matches. Errors that happen once the flo is stopping, for
+// instance because its parent context was canceled, are ignored.
+func (f *failer) record(err error) {
+	if !f.match(err) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.first == nil && f.ctx.Err() == nil {
+		f.first = err
+		f.cancel()
+	}
+}
+
+// err returns the error that stopped the flo, if any.
+func (f *failer) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.first
+}
@@ -0,0 +1,93 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+var errFatal = errors.New("fatal")
+
+func TestFailFast(t *testing.T) {
+	var n int
+	err := flo.NewBuilder(flo.WithFailFast(), flo.WithParallelism(1)).
+		Add(func(ctx context.Context) (int, error) {
+			n++
+			return n, nil
+		}).
+		Add(func(ctx context.Context, i int) (int, error) {
+			if i == 5 {
+				return 0, errFatal
+			}
+			return i, nil
+		}).
+		Add(func(ctx context.Context, i int) error { return nil }).
+		BuildAndExecute(context.Background())
+
+	var se *flo.StepError
+	if !errors.As(err, &se) || !errors.Is(err, errFatal) {
+		t.Fatalf("got %v, want a *flo.StepError wrapping %v", err, errFatal)
+	}
+	if se.Step != 2 || se.Input != 5 {
+		t.Errorf("got step %d and input %v, want 2 and 5", se.Step, se.Input)
+	}
+}
+
+func TestFailFastOn(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []string
+		wantErr error
+	}{
+		{"no match", []string{"flaky", "a", "flaky"}, nil},
+		{"match", []string{"flaky", "fatal", "a"}, errFatal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inCh := make(chan string, len(tt.inputs))
+			for _, s := range tt.inputs {
+				inCh <- s
+			}
+			close(inCh)
+			eh := &errHandle{}
+
+			err := flo.NewBuilder(flo.WithInput(inCh), flo.WithFailFastOn(func(err error) bool {
+				return errors.Is(err, errFatal)
+			})).
+				Add(middle).
+				Broadcast(flo.NewBuilder(flo.WithErrorHandler(eh.handleError)).Add(func(ctx context.Context, s string) error {
+					switch s {
+					case "FLAKY":
+						return errFlaky
+					case "FATAL":
+						return errFatal
+					}
+					return nil
+				})).
+				BuildAndExecute(context.Background())
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			if !eh.hasHandled {
+				t.Errorf("got false, want true")
+			}
+		})
+	}
+}
+
+func TestFailFastCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := flo.NewBuilder(flo.WithFailFast()).
+		Add(func(ctx context.Context) (string, error) {
+			cancel()
+			<-ctx.Done()
+			return "", ctx.Err()
+		}).
+		Add(end).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
@@ -0,0 +1,40 @@
+package flo
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// predicate is the Step registered by Builder.Filter.
+type predicate struct {
+	fn Step
+}
+
+// Filter registers a Step that decides which data continues on through the flo. The Step must have a signature of
+// func(context.Context, T) (bool, error). When it returns true the data it was given is passed along to the next step
+// unchanged, when it returns false the data is silently dropped. Unlike returning an error from a Step, dropping data
+// does not call the error handler. A filter may be used at any position in the flo that a
+// func(context.Context, T) (R, error) could be, and the next step should take in a T.
+func (b *Builder) Filter(fn Step, options ...StepOption) *Builder {
+	return b.Add(&predicate{fn: fn}, options...)
+}
+
+// Filter is the type safe equivalent of Builder.Filter.
+func Filter[T any](c Chain[T], s Stage[T, bool], options ...StepOption) Chain[T] {
+	c.b.Filter(s, options...)
+	return c
+}
+
+// handleFilter passes along the data the filter keeps. Could be any step in the flo.
+func (s *stepRunner) handleFilter(ctx context.Context, input interface{}, send sendFn) {
+	keep, err := s.call(stepContext(ctx), input)
+	if err != nil {
+		s.reportError(input, err)
+		return
+	}
+	if ok, _ := keep.(bool); !ok {
+		atomic.AddInt64(&s.metrics.dropped, 1)
+		return
+	}
+	send(ctx, s.outCh, input)
+}
@@ -0,0 +1,91 @@
+package flo_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+func TestFilterValidateFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *flo.Builder
+	}{
+		{"not a filter", flo.NewBuilder().Add(start).Filter(middle).Add(end)},
+		{"not a func", flo.NewBuilder().Add(start).Filter(7).Add(end)},
+		{"type mismatch", flo.NewBuilder().Add(start).Filter(isEven).Add(end)},
+		{"next step type mismatch", flo.NewBuilder().Add(startInt).Filter(isEven).Add(end)},
+		{"input chan type mismatch", flo.NewBuilder(flo.WithInput(make(chan string))).Filter(isEven).Add(end)},
+		{"output chan type mismatch", flo.NewBuilder(flo.WithOutput(make(chan string))).Add(startInt).Filter(isEven)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestFilterPassesTypesThrough(t *testing.T) {
+	err := flo.NewBuilder(flo.WithOutput(make(chan stringThing))).
+		Add(startStringThing).
+		Filter(func(ctx context.Context, s fmt.Stringer) (bool, error) { return true, nil }).
+		Add(middleStringThingToStringThing).
+		Filter(func(ctx context.Context, s fmt.Stringer) (bool, error) { return true, nil }).
+		Validate()
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestFilterBuildAndExecute(t *testing.T) {
+	inCh := make(chan int, 4)
+	for i := 1; i <= 4; i++ {
+		inCh <- i
+	}
+	close(inCh)
+	outCh := make(chan int, 4)
+	eh := &errHandle{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithOutput(outCh), flo.WithErrorHandler(eh.handleError)).
+		Filter(isEven).
+		Add(square).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := <-outCh + <-outCh; got != 20 {
+		t.Errorf("got %d, want 20", got)
+	}
+	if eh.hasHandled {
+		t.Errorf("got true, want false")
+	}
+	close(outCh)
+}
+
+func TestFilterChain(t *testing.T) {
+	inCh := make(chan int, 2)
+	inCh <- 1
+	inCh <- 2
+	close(inCh)
+	outCh := make(chan int, 2)
+
+	c := flo.Filter(flo.Input(flo.NewBuilder(), inCh), isEven)
+	err := flo.Output(flo.Then(c, square), outCh).BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := <-outCh; got != 4 {
+		t.Errorf("got %d, want 4", got)
+	}
+	close(outCh)
+}
+
+func isEven(ctx context.Context, i int) (bool, error) {
+	return i%2 == 0, nil
+}
@@ -0,0 +1,56 @@
+package flo
+
+import (
+	"context"
+	"reflect"
+)
+
+// flatMapper is the Step registered by Builder.FlatMap.
+type flatMapper struct {
+	fn Step
+	// each calls fn with every element of a slice returned by the Step.
+	each func(v interface{}, fn func(interface{}))
+}
+
+// FlatMap registers a Step that may produce zero or many outputs for each piece of data it takes in. The Step must
+// have a signature of func(context.Context, T) ([]R, error). Each element of the returned slice is sent along to the
+// next step individually, so the next step should take in an R rather than a []R. A flat map may be used at any
+// position in the flo that a func(context.Context, T) (R, error) could be.
+func (b *Builder) FlatMap(fn Step, options ...StepOption) *Builder {
+	return b.Add(&flatMapper{
+		fn: fn,
+		each: func(v interface{}, fn func(interface{})) {
+			rv := reflect.ValueOf(v)
+			for i := 0; i < rv.Len(); i++ {
+				fn(rv.Index(i).Interface())
+			}
+		},
+	}, options...)
+}
+
+// FlatMap is the type safe equivalent of Builder.FlatMap. The elements of the returned slices are sent along without
+// the use of reflection.
+func FlatMap[T, R any](c Chain[T], s Stage[T, []R], options ...StepOption) Chain[R] {
+	c.b.Add(&flatMapper{
+		fn: s,
+		each: func(v interface{}, fn func(interface{})) {
+			rs, _ := v.([]R)
+			for _, r := range rs {
+				fn(r)
+			}
+		},
+	}, options...)
+	return Chain[R]{b: c.b}
+}
+
+// handleFlatMap sends along each output the step produces. Could be any step in the flo.
+func (s *stepRunner) handleFlatMap(ctx context.Context, input interface{}, send sendFn) {
+	values, err := s.call(stepContext(ctx), input)
+	if err != nil {
+		s.reportError(input, err)
+		return
+	}
+	s.step.(*flatMapper).each(values, func(value interface{}) {
+		send(ctx, s.outCh, value)
+	})
+}
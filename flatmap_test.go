@@ -0,0 +1,72 @@
+package flo_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+func TestFlatMapValidateFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *flo.Builder
+	}{
+		{"not a flat map", flo.NewBuilder().Add(start).FlatMap(middle).Add(end)},
+		{"not a func", flo.NewBuilder().Add(start).FlatMap(7).Add(end)},
+		{"type mismatch", flo.NewBuilder().Add(startInt).FlatMap(words).Add(end)},
+		{"aligned against slice", flo.NewBuilder().Add(start).FlatMap(words).Add(func(ctx context.Context, s []string) error { return nil })},
+		{"output chan type mismatch", flo.NewBuilder(flo.WithOutput(make(chan []string))).Add(start).FlatMap(words)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestFlatMapBuildAndExecute(t *testing.T) {
+	inCh := make(chan string, 3)
+	inCh <- "a b"
+	inCh <- ""
+	inCh <- "c"
+	close(inCh)
+	c := &collector{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(2)).
+		FlatMap(words).
+		Add(middle).
+		Add(c.collect).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "A,B,C" {
+		t.Errorf("got %s, want A,B,C", got)
+	}
+}
+
+func TestFlatMapChain(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "a b"
+	close(inCh)
+	c := &collector{}
+
+	w := flo.FlatMap(flo.Input(flo.NewBuilder(), inCh), words)
+	err := flo.End(w, c.collect).BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "a,b" {
+		t.Errorf("got %s, want a,b", got)
+	}
+}
+
+func words(ctx context.Context, s string) ([]string, error) {
+	return strings.Fields(s), nil
+}
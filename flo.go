@@ -2,16 +2,19 @@
 // decently opinionated and making heavy use of reflection.
 //
 // The first thing you need to know about designing a flo is what kind of functions/methods it can work with. Flo's can
-// consist of one of three function signatures:
+// consist of one of four function signatures:
 //
 //  1. func (context.Context) (R, error)
 //  2. func (context.Context, T) (R, error)
 //  3. func (context.Context, T) error
+//  4. func (context.Context, T, func(R) error) error
 //
 // One can only be used as the first step of a flo. It is meant to act as a step the produces data without an input from
 // anywhere. Two can be used at any position in the flo, although if it is used as the first or last step in the flo
 // some extra configuration is expected. Three can only be used as the last step of a flo. It is mean to act as a step
-// that consumes data and does not send it along to anywhere else.
+// that consumes data and does not send it along to anywhere else. Four can be used anywhere two can. Rather than
+// returning a single R it calls the provided emit func once for each R it produces, which is useful when a step produces
+// a large or unbounded amount of data.
 //
 // Now lets break down the common parts of the step signatures. They all take in a context as their first parameter.
 // This the same context that is passed into the flo when BuildAndExecute is called. It is propagated throughout to
@@ -41,6 +44,13 @@
 // would not be nil. The flo validates all types before it begins to process any data. A Validate method is also exposed
 // from the flo.Builder should you want to validate your flo at test time.
 //
+// If you would rather have the compiler check that your steps line up, the Source, Stage and Sink types along with the
+// Begin, Input, Then, End and Output functions provide a type safe way to build the same flo. Steps registered this way
+// are also invoked without the use of reflection:
+//  c := flo.Begin(flo.NewBuilder(), step1)
+//  s := flo.Then(c, step2)
+//  err := flo.End(s, step3).BuildAndExecute(context.Background())
+//
 // For more detailed examples including how to configure a flo's parallelism and how to bridge a flo to other parts of
 // your codebase I recommend checkout out the examples folder in this repo.
 package flo
@@ -51,31 +61,68 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 )
 
 var (
 	errStepCnt              = errors.New("must register at least two steps")
 	errFirstStep            = errors.New("first step must have a signature of func(context.Context) (R, error) or func(context.Context, T) (R, error)")
-	errInteriorStep         = errors.New("interior step must have a signature of func(context.Context, T) (R, error)")
+	errInteriorStep         = errors.New("interior step must take in and output data, such as func(context.Context, T) (R, error)")
 	errLastStep             = errors.New("last step must have a signature of func(context.Context, T) error")
-	errStepType             = errors.New("a Step must be func with one of the following signatures: func(context.Context) (R, error), func(context.Context, T) (R, error), or func(context.Context, T) error")
+	errStepType             = errors.New("a Step must be func with one of the following signatures: func(context.Context) (R, error), func(context.Context, T) (R, error), func(context.Context, T) error, or func(context.Context, T, func(R) error) error")
 	errInputChType          = errors.New("a input channel must be of type <-chan T")
-	errInputChStepType      = errors.New("a input channel should only be registered when first step is of type func(context.Context, T) (R, error)")
+	errInputChStepType      = errors.New("a input channel should only be registered when first step takes in and outputs data, such as func(context.Context, T) (R, error)")
 	errOutputChType         = errors.New("an output channel must be of type chan<- T")
-	errOutputChStepType     = errors.New("an output channel should only be registered when last step is of type func(context.Context, T) (R, error)")
+	errOutputChStepType     = errors.New("an output channel should only be registered when last step takes in and outputs data, such as func(context.Context, T) (R, error)")
 	inputChTypeMismatchFmt  = "input channels type %s does not match the first steps input type %s"
 	outputChTypeMismatchFmt = "output channels type %s does not match the last steps output type %s"
-	typeMismatchFmt         = "Step %d: previous steps output type %s does not match current steps input type %s"
+	errBranchFirstStep      = errors.New("first step of a branch must have a signature of func(context.Context, T) (R, error) or func(context.Context, T) error")
+	errBranchInputCh        = errors.New("a branch must not register an input channel, it is fed by its parent flo")
+	errBranchStepCnt        = errors.New("a branch must register at least one step")
+	errBroadcastPosition    = errors.New("a broadcast must be the last step of a flo")
+	errBroadcastCnt         = errors.New("a broadcast must have at least one branch")
+	errRoutePosition        = errors.New("a route must be the last step of a flo")
+	errRouteCnt             = errors.New("a route must have at least one branch")
+	errRouterStep           = errors.New("a router must have a signature of func(context.Context, T) (K, error), where K is comparable")
+	errFilterStep           = errors.New("a filter must have a signature of func(context.Context, T) (bool, error)")
+	errFlatMapStep          = errors.New("a flat map must have a signature of func(context.Context, T) ([]R, error)")
+	errBatchSize            = errors.New("a batch must have a size of at least one")
+	errBatchType            = errors.New("a batch must be fed data of a single type")
+	errMergePosition        = errors.New("a merge must be the first step of a flo")
+	errMergeCnt             = errors.New("a merge must have at least one producer")
+	errProducerLastStep     = errors.New("last step of a producer must have a signature of func(context.Context) (R, error) or func(context.Context, T) (R, error)")
+	errProducerOutputCh     = errors.New("a producer must not register an output channel, its output is merged into its parent flo")
+	routeKeyTypeMismatchFmt = "route key %v of type %s does not match the routers key type %s"
+	routeKeyUncomparableFmt = "router returned key %v of type %T, which can not be compared"
+	typeMismatchFmt         = "Step %d (%s): previous steps output type %s does not match current steps input type %s"
+	stepFmt                 = "Step %d (%s): %w"
+	duplicateStepNameFmt    = "Step %d: step name %q is already in use"
 )
 
 // Builder is used to construct a flo(workflow).
 type Builder struct {
-	inCh        interface{}
+	// mu guards the wiring of the steps, so the flo's stats may be read while it is being started.
+	mu          sync.Mutex
+	name        string
+	inChs       []*inputChannel
 	outCh       interface{}
 	realChan    chan interface{}
+	send        func(context.Context, interface{})
 	steps       []*stepRunner
 	parallelism int
+	window      int
 	errHandler  func(error)
+	repanic     bool
+	middleware  []Middleware
+	failFast    func(error) bool
+
+	shutdown     ShutdownMode
+	drainTimeout time.Duration
+	closeOutput  bool
+	// forwarders tracks the goroutines that forward data from the input channels and to the output channel.
+	forwarders sync.WaitGroup
+
+	deadLetterHandler DeadLetterHandler
 }
 
 // Option that configures a Builder.
@@ -100,23 +147,43 @@ func WithErrorHandler(handler ErrorHandler) Option {
 }
 
 // WithInput configures the input channel that feeds the flo. This option is only valid if the first step registered in
-// the flo is of type func(context.Context, T) (R, errorr). In this case the ch should be of type chan T.
+// the flo is of type func(context.Context, T) (R, errorr). In this case the ch should be of type chan T. This option
+// may be provided more than once to merge several channels into the first step, in which case the flo will not shut
+// down until all of them are closed.
 func WithInput(ch interface{}) Option {
 	return func(b *Builder) {
-		b.inCh = ch
+		b.inChs = append(b.inChs, &inputChannel{ch: ch})
 	}
 }
 
 // WithOutput configures the output channel  of the flo. This is useful should you want to bridge the final output of the
 // flo with some of your other code. This option is only valid if the last step registered in the flo is of type
-// func(context.Context, T) (R, error). In this case the ch should be of type chan R. This channel should not be closed
-// until after the flo ends processing data. It will not be managed by the flo.
+// func(context.Context, T) (R, error). In this case the ch should be of type chan R. Every piece of data has been sent
+// to the channel by the time BuildAndExecute returns, so it may be closed from then on. Configure WithCloseOutput to have
+// the flo close it instead.
 func WithOutput(ch interface{}) Option {
 	return func(b *Builder) {
 		b.outCh = ch
 	}
 }
 
+// WithCloseOutput configures the flo to close its output channel once the last piece of data has been sent to it. This
+// allows the output channel to be ranged over while the flo runs.
+//
+//  go func() {
+//      err = flo.NewBuilder(flo.WithInput(in), flo.WithOutput(out), flo.WithCloseOutput()).
+//                Add(parse).
+//                BuildAndExecute(ctx)
+//  }()
+//  for record := range out {
+//      ...
+//  }
+func WithCloseOutput() Option {
+	return func(b *Builder) {
+		b.closeOutput = true
+	}
+}
+
 // NewBuilder creates a Builder, used to construct a flo. The flo will not begin to process any data until
 // BuildAndExecute is called.
 func NewBuilder(options ...Option) *Builder {
@@ -136,157 +203,351 @@ func (b *Builder) Add(s Step, options ...StepOption) *Builder {
 	sr := &stepRunner{
 		step:        s,
 		parallelism: b.parallelism,
+		window:      b.window,
 		wg:          &sync.WaitGroup{},
 		errHandler:  b.errHandler,
+		index:       len(b.steps) + 1,
+		repanic:     b.repanic,
+		metrics:     &stepMetrics{},
+		middleware:  append([]Middleware(nil), b.middleware...),
+
+		deadLetterHandler: b.deadLetterHandler,
 	}
 	for i := range options {
 		options[i](sr)
 	}
+	if sr.name == "" {
+		sr.name = defaultStepName(s)
+	} else {
+		sr.named = true
+	}
 	b.steps = append(b.steps, sr)
 	return b
 }
 
 // BuildAndExecute the flo. This will validate all steps registered to the pipeline. If validation fails an error is
 // returned and no data will be processed. If validation is successful the steps will begin to process data and this
-// method will block until the provdied context is canceled or the input channel closed, if one was registered.
+// method will block until the provdied context is canceled or the input channel closed, if one was registered, and
+// every goroutine started by the flo has exited, other than calls to a step that timed out, see WithStepTimeout, once
+// the flo is aborted. If the flo was configured WithFailFast, the error that stopped it is returned.
+//
+// By default canceling the context drains the flo, see ShutdownDrain, so data that has already been taken in is still
+// sent through its steps. Configure WithShutdown(ShutdownAbort) to drop that data instead.
 func (b *Builder) BuildAndExecute(ctx context.Context) error {
-	err := b.Validate()
+	f, err := b.Start(ctx)
 	if err != nil {
 		return err
 	}
+	return f.Wait()
+}
 
-	// wire up the steps and start worker pools
+// launch starts the flo, feeding it from its input channels if any were registered.
+func (b *Builder) launch(ctx context.Context) {
+	var in chan interface{}
+	if len(b.inChs) > 0 {
+		in = b.launchInputChannel(ctx)
+	}
+	b.start(ctx, in)
+}
+
+// start wires up the steps and starts their worker pools. The first step will receive data from in, if it is not nil.
+func (b *Builder) start(ctx context.Context, in chan interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	for i := range b.steps {
 		if i == 0 {
-			if b.inCh != nil {
-				b.steps[i].registerInput(b.launchInputChannel())
+			if in != nil {
+				b.steps[i].registerInput(in)
 			}
 		} else {
 			b.steps[i].registerInput(b.steps[i-1].output())
 		}
 		// allocate output channel, if needed, to avoid data race
-		if b.steps[i].sType != onlyIn {
+		if b.steps[i].sType.hasOutput() {
 			b.steps[i].output()
 		}
 		b.steps[i].start(ctx)
 	}
 
 	if b.outCh != nil {
-		b.launchOutputChannel()
+		b.launchOutputChannel(ctx)
 	}
-
-	b.awaitShutdown()
-	return nil
 }
 
 // Validate makes sure the pipeline can process data. It ensures all registered steps are of the right type and that
 // their input and output types line up. This is the methodd that BuildAndExecute calls. It is exposed mainly for
 // testing purposes so that end users of this api can find out at compile time if their pipeline is set up correctly.
 func (b *Builder) Validate() error {
-	stepCnt := len(b.steps)
-	if stepCnt < 2 {
+	if len(b.steps) < 2 {
 		return errStepCnt
 	}
+	_, err := b.validate(nil, false)
+	return err
+}
+
+// validate checks the steps of a flo and returns the possible output types of its last step. in holds the types of data
+// fed into the first step by a parent flo, it is only set when the flo is a branch of another. producer is set when the
+// output of the flo is merged into another.
+func (b *Builder) validate(in []reflect.Type, producer bool) ([]reflect.Type, error) {
+	stepCnt := len(b.steps)
 
 	// loop through and validate steps
 	var (
-		input      reflect.Type
-		output     reflect.Type
-		prevOutput reflect.Type
-		st         stepType
+		input       reflect.Type
+		output      reflect.Type
+		prevOutputs = in
+		st          stepType
+		names       = make(map[string]bool)
 	)
 	for i := range b.steps {
+		if name := b.steps[i].name; b.steps[i].named {
+			if names[name] {
+				return nil, fmt.Errorf(duplicateStepNameFmt, i+1, name)
+			}
+			names[name] = true
+		}
+
 		st = typeOfStep(b.steps[i].step)
+		fed := i > 0 || in != nil
 		// some initial validation
 		if st == invalid {
-			return errStepType
-		} else if i == 0 && st == onlyIn {
-			return errFirstStep
-		} else if i == stepCnt-1 && st == onlyOut {
-			return errLastStep
-		} else if 0 < i && i < stepCnt-1 && st != inOut {
-			return errInteriorStep
+			return nil, b.steps[i].validationError(errStepType)
+		} else if !fed && (st == onlyIn || st == fanOut || st == route) {
+			return nil, b.steps[i].validationError(errFirstStep)
+		} else if i == 0 && in != nil && st == onlyOut {
+			return nil, b.steps[i].validationError(errBranchFirstStep)
+		} else if fed && st == fanIn {
+			return nil, b.steps[i].validationError(errMergePosition)
+		} else if i == stepCnt-1 && st == onlyOut && !producer {
+			return nil, b.steps[i].validationError(errLastStep)
+		} else if i == stepCnt-1 && !st.hasOutput() && producer {
+			return nil, b.steps[i].validationError(errProducerLastStep)
+		} else if i < stepCnt-1 && st == fanOut {
+			return nil, b.steps[i].validationError(errBroadcastPosition)
+		} else if i < stepCnt-1 && st == route {
+			return nil, b.steps[i].validationError(errRoutePosition)
+		} else if 0 < i && i < stepCnt-1 && !st.isTransform() {
+			return nil, b.steps[i].validationError(errInteriorStep)
+		} else if fed && st == onlyOut {
+			return nil, b.steps[i].validationError(errInteriorStep)
 		}
 
 		// set the stepRunner's type
 		b.steps[i].sType = st
 
-		// set variables for input/output types
+		// validate any wrapped Steps and set variables for input/output types
 		switch st {
-		case onlyOut:
-			output = reflect.TypeOf(b.steps[i].step).Out(0)
-		case inOut:
-			input = reflect.TypeOf(b.steps[i].step).In(1)
-			output = reflect.TypeOf(b.steps[i].step).Out(0)
-		case onlyIn:
-			input = reflect.TypeOf(b.steps[i].step).In(1)
-		}
-
-		if i == 0 {
-			prevOutput = output
+		case route:
+			fn := b.steps[i].step.(*router).fn
+			if typeOfStep(fn) != inOut || !reflect.TypeOf(fn).Out(0).Comparable() {
+				return nil, b.steps[i].validationError(errRouterStep)
+			}
+		case filter:
+			fn := b.steps[i].step.(*predicate).fn
+			if typeOfStep(fn) != inOut || reflect.TypeOf(fn).Out(0) != reflect.TypeOf(true) {
+				return nil, b.steps[i].validationError(errFilterStep)
+			}
+		case flatMap:
+			fn := b.steps[i].step.(*flatMapper).fn
+			if typeOfStep(fn) != inOut || reflect.TypeOf(fn).Out(0).Kind() != reflect.Slice {
+				return nil, b.steps[i].validationError(errFlatMapStep)
+			}
+		case batch:
+			bt := b.steps[i].step.(*batcher)
+			if bt.size < 1 {
+				return nil, b.steps[i].validationError(errBatchSize)
+			}
+			if bt.elem == nil {
+				types := prevOutputs
+				if !fed {
+					types = b.inputChannelTypes()
+				}
+				if len(types) == 0 {
+					return nil, b.steps[i].validationError(errFirstStep)
+				}
+				for _, t := range types[1:] {
+					if t != types[0] {
+						return nil, b.steps[i].validationError(errBatchType)
+					}
+				}
+				bt.elem = types[0]
+			}
+		case fanIn:
+			outputs, err := b.steps[i].step.(*merge).validate()
+			if err != nil {
+				return nil, b.steps[i].validationError(err)
+			}
+			prevOutputs = outputs
+			b.steps[i].outputs = outputs
 			continue
 		}
+		input = b.steps[i].inputType()
+		output = b.steps[i].outputType()
 
 		// make sure types align
-		if input.Kind() == reflect.Interface {
-			if !prevOutput.Implements(input) {
-				return fmt.Errorf(typeMismatchFmt, i+1, prevOutput, input)
+		if fed && input != nil {
+			for _, prevOutput := range prevOutputs {
+				if !typesAlign(prevOutput, input) {
+					return nil, fmt.Errorf(typeMismatchFmt, i+1, b.steps[i].name, prevOutput, input)
+				}
+			}
+		}
+
+		if br, ok := b.steps[i].step.(brancher); ok {
+			err := br.validate(prevOutputs)
+			if err != nil {
+				return nil, b.steps[i].validationError(err)
 			}
-		} else if prevOutput != input {
-			return fmt.Errorf(typeMismatchFmt, i+1, prevOutput, input)
 		}
-		prevOutput = output
+
+		// filters pass data through unchanged, so the next step may take in whatever was given to the filter
+		if st != filter || !fed {
+			prevOutputs = nil
+			if output != nil {
+				prevOutputs = []reflect.Type{output}
+			}
+		}
+		b.steps[i].outputs = prevOutputs
 	}
 
-	// validate input channel
-	if b.inCh != nil {
-		err := validateInputChannel(b.inCh, b.steps[0])
+	// validate input channels
+	if len(b.inChs) > 0 && in != nil {
+		return nil, errBranchInputCh
+	}
+	for _, ic := range b.inChs {
+		err := validateInputChannel(ic.ch, b.steps[0])
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// validate input channel
+	// validate output channel
 	if b.outCh != nil {
+		if producer {
+			return nil, errProducerOutputCh
+		}
 		err := validateOutputChannel(b.outCh, b.steps[len(b.steps)-1])
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return prevOutputs, nil
 }
 
-func (b *Builder) launchInputChannel() chan interface{} {
-	v := reflect.ValueOf(b.inCh)
-	realChan := make(chan interface{}, v.Cap())
+func (b *Builder) launchInputChannel(ctx context.Context) chan interface{} {
+	var (
+		capacity int
+		wg       sync.WaitGroup
+	)
+	for _, ic := range b.inChs {
+		capacity += reflect.ValueOf(ic.ch).Cap()
+	}
+	realChan := make(chan interface{}, capacity)
 	b.realChan = realChan
-	go func() {
-		for {
-			x, ok := v.Recv()
-			if !ok {
-				close(realChan)
-				return
+
+	intake := intakeContext(ctx)
+	for _, ic := range b.inChs {
+		recv := ic.receiver()
+		wg.Add(1)
+		b.forwarders.Add(1)
+		go func() {
+			defer b.forwarders.Done()
+			defer wg.Done()
+			for {
+				x, ok := recv(intake)
+				if !ok {
+					return
+				}
+				if !send(ctx, realChan, x) {
+					return
+				}
 			}
-			realChan <- x.Interface()
-		}
+		}()
+	}
+	b.forwarders.Add(1)
+	go func() {
+		defer b.forwarders.Done()
+		wg.Wait()
+		close(realChan)
 	}()
 
 	return realChan
 }
 
-func (b *Builder) launchOutputChannel() {
+// launchOutputChannel forwards the output of the last step to the flo's output channel. Once the flo is aborted data is
+// no longer sent to the output channel, so the flo does not block on a consumer that has gone away.
+func (b *Builder) launchOutputChannel(ctx context.Context) {
 	v := reflect.ValueOf(b.outCh)
+	send := b.send
+	if send == nil {
+		send = func(ctx context.Context, output interface{}) {
+			reflect.Select([]reflect.SelectCase{
+				{Dir: reflect.SelectSend, Chan: v, Send: reflect.ValueOf(output)},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			})
+		}
+	}
 	lastStepOutput := b.steps[len(b.steps)-1].output()
+	b.forwarders.Add(1)
 	go func() {
+		defer b.forwarders.Done()
 		for output := range lastStepOutput {
-			v.Send(reflect.ValueOf(output))
+			send(ctx, output)
+		}
+		if b.closeOutput {
+			v.Close()
 		}
 	}()
 }
 
+// inputChannelTypes returns the types of data sent on the flo's input channels.
+func (b *Builder) inputChannelTypes() []reflect.Type {
+	var types []reflect.Type
+	for _, ic := range b.inChs {
+		if t := reflect.TypeOf(ic.ch); t != nil && t.Kind() == reflect.Chan {
+			types = append(types, t.Elem())
+		}
+	}
+	return types
+}
+
+// inputChannel is a channel registered to feed the first step of a flo.
+type inputChannel struct {
+	ch   interface{}
+	recv func(context.Context) (interface{}, bool)
+}
+
+// receiver returns a function that receives the next item from the channel. It reports false once the channel is
+// closed or the context is canceled.
+func (ic *inputChannel) receiver() func(context.Context) (interface{}, bool) {
+	if ic.recv != nil {
+		return ic.recv
+	}
+
+	v := reflect.ValueOf(ic.ch)
+	return func(ctx context.Context) (interface{}, bool) {
+		if ctx.Done() == nil {
+			x, ok := v.Recv()
+			if !ok {
+				return nil, false
+			}
+			return x.Interface(), true
+		}
+
+		chosen, x, ok := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: v},
+		})
+		if chosen == 0 || !ok {
+			return nil, false
+		}
+		return x.Interface(), true
+	}
+}
+
 func validateInputChannel(inCh interface{}, sr *stepRunner) error {
-	if sr.sType != inOut {
+	if !sr.sType.isTransform() {
 		return errInputChStepType
 	}
 
@@ -298,13 +559,9 @@ func validateInputChannel(inCh interface{}, sr *stepRunner) error {
 	}
 
 	// make sure types align
-	input := reflect.TypeOf(sr.step).In(1)
+	input := sr.inputType()
 	t = t.Elem()
-	if input.Kind() == reflect.Interface {
-		if !t.Implements(input) {
-			return fmt.Errorf(inputChTypeMismatchFmt, t, input)
-		}
-	} else if t != input {
+	if !typesAlign(t, input) {
 		return fmt.Errorf(inputChTypeMismatchFmt, t, input)
 	}
 
@@ -312,7 +569,7 @@ func validateInputChannel(inCh interface{}, sr *stepRunner) error {
 }
 
 func validateOutputChannel(outCh interface{}, sr *stepRunner) error {
-	if sr.sType != inOut {
+	if !sr.sType.isTransform() {
 		return errOutputChStepType
 	}
 
@@ -324,23 +581,45 @@ func validateOutputChannel(outCh interface{}, sr *stepRunner) error {
 	}
 
 	// make sure types align
-	output := reflect.TypeOf(sr.step).Out(0)
+	outputs := sr.outputs
+	if outputs == nil {
+		outputs = []reflect.Type{sr.outputType()}
+	}
 	t = t.Elem()
-	if t.Kind() == reflect.Interface {
-		if !output.Implements(t) {
+	for _, output := range outputs {
+		if !typesAlign(output, t) {
 			return fmt.Errorf(outputChTypeMismatchFmt, t, output)
 		}
-	} else if t != output {
-		return fmt.Errorf(outputChTypeMismatchFmt, t, output)
 	}
 
 	return nil
 }
 
+// typesAlign reports whether data of type out may be passed along to something that takes an in.
+func typesAlign(out, in reflect.Type) bool {
+	if in.Kind() == reflect.Interface {
+		return out.Implements(in)
+	}
+	return out == in
+}
+
 // typeOfStep uses reflection to determine what type of function was passed in as a step.
 func typeOfStep(s Step) stepType {
-	if s == nil {
+	switch s.(type) {
+	case nil:
 		return invalid
+	case *broadcast:
+		return fanOut
+	case *merge:
+		return fanIn
+	case *router:
+		return route
+	case *predicate:
+		return filter
+	case *flatMapper:
+		return flatMap
+	case *batcher:
+		return batch
 	}
 
 	t := reflect.TypeOf(s)
@@ -348,6 +627,15 @@ func typeOfStep(s Step) stepType {
 		return invalid
 	}
 
+	if t.NumIn() == 3 {
+		if t.In(0) != reflect.TypeOf((*context.Context)(nil)).Elem() ||
+			!isEmitFunc(t.In(2)) ||
+			t.NumOut() != 1 || t.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+			return invalid
+		}
+		return emitter
+	}
+
 	if t.NumIn() < 1 || t.NumIn() > 2 ||
 		t.NumOut() < 1 || t.NumOut() > 2 ||
 		t.NumIn() == 1 && t.NumOut() == 1 {
@@ -377,8 +665,18 @@ func typeOfStep(s Step) stepType {
 	return inOut
 }
 
+// isEmitFunc reports whether t is a func(R) error, the type of the callback given to an emitter step.
+func isEmitFunc(t reflect.Type) bool {
+	return t.Kind() == reflect.Func &&
+		t.NumIn() == 1 && t.NumOut() == 1 &&
+		t.Out(0) == reflect.TypeOf((*error)(nil)).Elem()
+}
+
+// awaitShutdown waits for each step to finish processing data, closing the channels between them as it goes, and then
+// for the data to be forwarded to the output channel.
 func (b *Builder) awaitShutdown() {
 	for i := range b.steps {
 		b.steps[i].awaitShutdown()
 	}
+	b.forwarders.Wait()
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestTypeOfStep(t *testing.T) {
@@ -33,6 +34,16 @@ func TestTypeOfStep(t *testing.T) {
 		{"onlyIn", func(ctx context.Context, b bool) error { return nil }, onlyIn},
 		{"inOut", func(ctx context.Context, b bool) (bool, error) { return false, nil }, inOut},
 		{"onlyOut", func(ctx context.Context) (bool, error) { return false, nil }, onlyOut},
+		{"emitter", func(ctx context.Context, b bool, emit func(int) error) error { return nil }, emitter},
+		{"invalid emitter no out", func(ctx context.Context, b bool, emit func(int) error) {}, invalid},
+		{"invalid emitter wrong emit", func(ctx context.Context, b bool, emit func(int)) error { return nil }, invalid},
+		{"invalid emitter wrong ctx", func(i int, b bool, emit func(int) error) error { return nil }, invalid},
+		{"fanOut", &broadcast{}, fanOut},
+		{"fanIn", &merge{}, fanIn},
+		{"route", &router{}, route},
+		{"filter", &predicate{}, filter},
+		{"flatMap", &flatMapper{}, flatMap},
+		{"batch", &batcher{}, batch},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -159,3 +170,171 @@ func createReceiveChan() <-chan bool {
 func inOutFn(ctx context.Context, s string) (string, error) {
 	return "", nil
 }
+
+func TestWithOrdering(t *testing.T) {
+	f := NewBuilder()
+	if f.window != 0 {
+		t.Fatalf("got %d, want 0", f.window)
+	}
+
+	f = NewBuilder(WithOrdering(-5))
+	if f.window != 0 {
+		t.Fatalf("got %d, want 0", f.window)
+	}
+
+	f = NewBuilder(WithOrdering(5))
+	f.Add(inOutFn)
+	if f.steps[0].window != 5 {
+		t.Fatalf("got %d, want 5", f.steps[0].window)
+	}
+
+	f.Add(inOutFn, WithStepOrdering(0))
+	if f.steps[1].window != 0 {
+		t.Fatalf("got %d, want 0", f.steps[1].window)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"defaults", RetryPolicy{}, 1, 100 * time.Millisecond},
+		{"defaults grow", RetryPolicy{}, 3, 400 * time.Millisecond},
+		{"initial", RetryPolicy{InitialBackoff: time.Second}, 1, time.Second},
+		{"multiplier", RetryPolicy{InitialBackoff: time.Second, Multiplier: 3}, 3, 9 * time.Second},
+		{"capped", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}, 10, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.backoff(tt.attempt); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	p := RetryPolicy{InitialBackoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(1); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("got %v, want between 500ms and 1s", got)
+		}
+	}
+}
+
+func TestWithStepRetry(t *testing.T) {
+	f := NewBuilder().Add(inOutFn, WithStepRetry(RetryPolicy{MaxAttempts: 1}))
+	if f.steps[0].retry != nil {
+		t.Fatalf("got %v, want nil", f.steps[0].retry)
+	}
+
+	f.Add(inOutFn, WithStepRetry(RetryPolicy{MaxAttempts: 3}))
+	if f.steps[1].retry == nil || f.steps[1].retry.MaxAttempts != 3 {
+		t.Fatalf("got %v, want 3 max attempts", f.steps[1].retry)
+	}
+}
+
+func TestWithRepanic(t *testing.T) {
+	panicky := func(ctx context.Context, s string) (string, error) { panic("boom") }
+	tests := []struct {
+		name    string
+		b       *Builder
+		repanic bool
+	}{
+		{"default", NewBuilder().Add(panicky), false},
+		{"flo wide", NewBuilder(WithRepanic()).Add(panicky), true},
+		{"per step", NewBuilder().Add(panicky, WithStepRepanic()), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.b.steps[0]
+			s.sType = inOut
+			s.compile()
+
+			defer func() {
+				if r := recover(); (r != nil) != tt.repanic {
+					t.Errorf("got %v, want panic to be %v", r, tt.repanic)
+				}
+			}()
+			if _, err := s.call(context.Background(), "a"); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestBreakerStates(t *testing.T) {
+	var states []BreakerState
+	s := &stepRunner{}
+	WithStepBreaker(BreakerPolicy{
+		Threshold:     2,
+		CoolDown:      10 * time.Millisecond,
+		OnStateChange: func(from, to BreakerState) { states = append(states, to) },
+	})(s)
+	br := s.breaker
+	ctx := context.Background()
+
+	br.record(fmt.Errorf("1"))
+	br.record(nil)
+	br.record(fmt.Errorf("1"))
+	if br.state != BreakerClosed {
+		t.Fatalf("got %v, want %v", br.state, BreakerClosed)
+	}
+	br.record(fmt.Errorf("2"))
+	if err := br.acquire(ctx, false); err != ErrBreakerOpen {
+		t.Fatalf("got %v, want %v", err, ErrBreakerOpen)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := br.acquire(ctx, false); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := br.acquire(ctx, false); err != ErrBreakerOpen {
+		t.Fatalf("got %v, want %v while waiting on the trial", err, ErrBreakerOpen)
+	}
+	br.record(fmt.Errorf("3"))
+	if br.state != BreakerOpen {
+		t.Fatalf("got %v, want %v", br.state, BreakerOpen)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := br.acquire(ctx, false); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	br.record(nil)
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if fmt.Sprint(states) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", states, want)
+	}
+}
+
+type namedSteps struct{}
+
+func (namedSteps) method(ctx context.Context, s string) (string, error) {
+	return s, nil
+}
+
+func TestDefaultStepName(t *testing.T) {
+	tests := []struct {
+		name string
+		step Step
+		want string
+	}{
+		{"func", inOutFn, "inOutFn"},
+		{"method value", namedSteps{}.method, "namedSteps.method"},
+		{"closure", func(ctx context.Context, s string) error { return nil }, "TestDefaultStepName.func1"},
+		{"filter", &predicate{fn: inOutFn}, "inOutFn"},
+		{"broadcast", &broadcast{}, "broadcast"},
+		{"batch", &batcher{}, "batch"},
+		{"not a func", 7, "int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultStepName(tt.step); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -184,6 +184,150 @@ func TestFloBuildAndExecuteWithInOutChannels(t *testing.T) {
 	close(outputChannel)
 }
 
+func TestFloBuildAndExecuteWaitsForOutput(t *testing.T) {
+	inputChannel := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		inputChannel <- i
+	}
+	close(inputChannel)
+	outputChannel := make(chan int)
+	done := make(chan struct{})
+	var received int
+	go func() {
+		for range outputChannel {
+			time.Sleep(time.Millisecond)
+			received++
+		}
+		close(done)
+	}()
+
+	err := flo.NewBuilder(flo.WithInput(inputChannel), flo.WithOutput(outputChannel)).
+		Add(addInts).
+		Add(addInts).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// every piece of data has been sent to the output channel, so it is safe to close
+	close(outputChannel)
+	<-done
+	if received != 10 {
+		t.Errorf("got %d received, want 10", received)
+	}
+}
+
+func TestFloCloseOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		b    func(in chan int, out chan int) *flo.Builder
+	}{
+		{"reflection", func(in chan int, out chan int) *flo.Builder {
+			return flo.NewBuilder(flo.WithInput(in), flo.WithOutput(out), flo.WithCloseOutput()).Add(addInts).Add(addInts)
+		}},
+		{"type safe", func(in chan int, out chan int) *flo.Builder {
+			c := flo.Input(flo.NewBuilder(flo.WithCloseOutput()), in)
+			return flo.Output(flo.Then(flo.Then(c, addInts), addInts), out)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputChannel := make(chan int, 3)
+			inputChannel <- 1
+			inputChannel <- 2
+			inputChannel <- 3
+			close(inputChannel)
+			outputChannel := make(chan int)
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- tt.b(inputChannel, outputChannel).BuildAndExecute(context.Background())
+			}()
+
+			var sum int
+			for i := range outputChannel {
+				sum += i
+			}
+			if sum != 24 {
+				t.Errorf("got %d, want 24", sum)
+			}
+			if err := <-errCh; err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestFloValidateEmitter(t *testing.T) {
+	err := flo.NewBuilder().Add(start).Add(emitWords).Add(end).Validate()
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	err = flo.NewBuilder().Add(start).Add(emitWords).Add(read).Validate()
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+
+	err = flo.NewBuilder(flo.WithInput(make(chan string)), flo.WithOutput(make(chan int))).Add(emitWords).Add(emitWords).Validate()
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+}
+
+func TestFloBuildAndExecuteEmitter(t *testing.T) {
+	inputChannel := make(chan string, 2)
+	inputChannel <- "Hello World"
+	inputChannel <- "Bye"
+	close(inputChannel)
+	outputChannel := make(chan string, 3)
+
+	err := flo.NewBuilder(flo.WithInput(inputChannel), flo.WithOutput(outputChannel)).
+		Add(emitWords).
+		Add(middle).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	got := map[string]bool{<-outputChannel: true, <-outputChannel: true, <-outputChannel: true}
+	if !got["HELLO"] || !got["WORLD"] || !got["BYE"] {
+		t.Fatalf("got %v, want HELLO, WORLD and BYE", got)
+	}
+	close(outputChannel)
+}
+
+func TestFloEmitterFailsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	inputChannel := make(chan string, 1)
+	inputChannel <- "Hello"
+	close(inputChannel)
+	emitErr := make(chan error, 1)
+
+	err := flo.NewBuilder(flo.WithInput(inputChannel)).
+		Add(func(ctx context.Context, s string, emit func(string) error) error {
+			for {
+				if err := emit(s); err != nil {
+					emitErr <- err
+					return err
+				}
+			}
+		}).
+		Add(func(ctx context.Context, s string) error {
+			cancel()
+			<-ctx.Done()
+			return nil
+		}).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := <-emitErr; got != context.Canceled {
+		t.Fatalf("got %v, want %v", got, context.Canceled)
+	}
+}
+
 // Benchmark flo vs non-flo
 
 func BenchmarkFlo(b *testing.B) {
@@ -305,6 +449,15 @@ func addInts(ctx context.Context, i int) (int, error) {
 	return i + i, nil
 }
 
+func emitWords(ctx context.Context, s string, emit func(string) error) error {
+	for _, w := range strings.Fields(s) {
+		if err := emit(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func read(ctx context.Context, r io.Reader) error {
 	return nil
 }
@@ -1 +1,3 @@
 module github.com/codyoss/flo
+
+go 1.18
@@ -0,0 +1,116 @@
+package flo
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter. A single Limiter may be shared by several steps, or several flos, to cap
+// their combined rate with WithStepLimiter.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a Limiter that allows perSecond events per second, with bursts of up to burst events. A burst of
+// less than one is treated as one.
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Wait blocks until the Limiter allows an event to happen or the ctx is done, in which case the ctx's error is
+// returned.
+func (l *Limiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token from the bucket, returning how long to wait until the token is available.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	if l.rate <= 0 {
+		// nothing is ever allowed past the burst
+		return math.MaxInt64
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve that was never used.
+func (l *Limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// WithStepRateLimit configures a step to be called at most perSecond times per second, with bursts of up to burst
+// calls, across all of its workers. Workers wait for the rate limit before calling the step, should the flo's context
+// be canceled while waiting the context's error is reported to the error handler. Each retry of a step counts towards
+// its rate limit. Rate limits have no effect on batches.
+func WithStepRateLimit(perSecond float64, burst int) StepOption {
+	return WithStepLimiter(NewLimiter(perSecond, burst))
+}
+
+// WithStepLimiter configures a step to be rate limited by l, see WithStepRateLimit for details. The same Limiter may be
+// given to several steps to cap their combined rate.
+//
+//  api := flo.NewLimiter(10, 5)
+//  err := flo.NewBuilder(flo.WithInput(ch)).
+//             Add(lookup, flo.WithStepLimiter(api)).
+//             Add(update, flo.WithStepLimiter(api)).
+//             BuildAndExecute(ctx)
+func WithStepLimiter(l *Limiter) StepOption {
+	return func(s *stepRunner) {
+		s.limiter = l
+	}
+}
+
+// limitCall returns a callFn that waits on l before calling fn.
+func limitCall(fn callFn, l *Limiter) callFn {
+	return func(ctx context.Context, in interface{}) (interface{}, error) {
+		if err := l.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return fn(ctx, in)
+	}
+}
@@ -0,0 +1,72 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestStepRateLimit(t *testing.T) {
+	inCh := make(chan string, 6)
+	for i := 0; i < 6; i++ {
+		inCh <- "a"
+	}
+	close(inCh)
+	c := &collector{}
+
+	begin := time.Now()
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(3)).
+		Add(middle, flo.WithStepRateLimit(100, 2)).
+		Add(c.collect).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// the first two calls are allowed by the burst, the other four are spaced 10ms apart
+	if elapsed := time.Since(begin); elapsed < 30*time.Millisecond {
+		t.Errorf("got %v, want at least 30ms", elapsed)
+	}
+	if got := c.sorted(); got != "A,A,A,A,A,A" {
+		t.Errorf("got %s, want A,A,A,A,A,A", got)
+	}
+}
+
+func TestStepLimiterShared(t *testing.T) {
+	inCh := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		inCh <- "a"
+	}
+	close(inCh)
+	l := flo.NewLimiter(100, 1)
+
+	begin := time.Now()
+	err := flo.NewBuilder(flo.WithInput(inCh)).
+		Add(middle, flo.WithStepLimiter(l)).
+		Add(end, flo.WithStepLimiter(l)).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// six calls share a single limiter, the first is allowed by the burst
+	if elapsed := time.Since(begin); elapsed < 40*time.Millisecond {
+		t.Errorf("got %v, want at least 40ms", elapsed)
+	}
+}
+
+func TestLimiterWaitCanceled(t *testing.T) {
+	l := flo.NewLimiter(0, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,54 @@
+package flo
+
+import "context"
+
+// StepInfo describes the step a Middleware is wrapping.
+type StepInfo struct {
+	// Step is the position, starting at one, of the step within the Builder it was registered with.
+	Step int
+	// Name is the name of the step, see WithStepName.
+	Name string
+}
+
+// Handler calls a step, or the next Middleware wrapping it, for a single piece of data. For steps that do not take in
+// data the input is nil, and for steps that do not send data along the returned value is nil. Otherwise the returned
+// value is what the step returned, such as whether a filter keeps the data or the key a router picked.
+type Handler func(ctx context.Context, input interface{}) (interface{}, error)
+
+// Middleware wraps each call to a step, which is useful for cross-cutting concerns like logging and tracing. A
+// Middleware should call next to continue on to the step, and may change the context, input, returned value or error
+// along the way.
+//
+//  logging := func(ctx context.Context, info flo.StepInfo, input interface{}, next flo.Handler) (interface{}, error) {
+//      out, err := next(ctx, input)
+//      log.Printf("step %d: %v -> %v, %v", info.Step, input, out, err)
+//      return out, err
+//  }
+type Middleware func(ctx context.Context, info StepInfo, input interface{}, next Handler) (interface{}, error)
+
+// WithMiddleware configures middleware that wraps every step of the flo. Middleware is called in the order it is
+// given, the first Middleware being the outermost. Middleware has no effect on batches.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(b *Builder) {
+		b.middleware = append(b.middleware, middleware...)
+	}
+}
+
+// WithStepMiddleware configures middleware that wraps a step. It is called after any middleware configured for the
+// flo with WithMiddleware.
+func WithStepMiddleware(middleware ...Middleware) StepOption {
+	return func(s *stepRunner) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+// wrapMiddleware returns a callFn that calls fn through each Middleware.
+func wrapMiddleware(fn callFn, info StepInfo, middleware []Middleware) callFn {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw, next := middleware[i], fn
+		fn = func(ctx context.Context, in interface{}) (interface{}, error) {
+			return mw(ctx, info, in, Handler(next))
+		}
+	}
+	return fn
+}
@@ -0,0 +1,96 @@
+package flo_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+type recorder struct {
+	sync.Mutex
+	calls []string
+}
+
+func (r *recorder) middleware(name string) flo.Middleware {
+	return func(ctx context.Context, info flo.StepInfo, input interface{}, next flo.Handler) (interface{}, error) {
+		out, err := next(ctx, input)
+		if err != nil {
+			return out, err
+		}
+		r.Lock()
+		defer r.Unlock()
+		r.calls = append(r.calls, fmt.Sprintf("%s %d: %v -> %v", name, info.Step, input, out))
+		return out, err
+	}
+}
+
+func (r *recorder) sorted() string {
+	r.Lock()
+	defer r.Unlock()
+	sort.Strings(r.calls)
+	return strings.Join(r.calls, ",")
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &recorder{}
+	var sent bool
+
+	err := flo.NewBuilder(flo.WithParallelism(1), flo.WithMiddleware(r.middleware("flo"))).
+		Add(func(ctx context.Context) (string, error) {
+			if sent {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+			sent = true
+			return "a", nil
+		}).
+		Add(middle, flo.WithStepMiddleware(r.middleware("step"))).
+		Add(func(ctx context.Context, s string) error {
+			cancel()
+			return nil
+		}).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	want := "flo 1: <nil> -> a,flo 2: a -> A,flo 3: A -> <nil>,step 2: a -> A"
+	if got := r.sorted(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "a"
+	close(inCh)
+	var order []string
+	named := func(name string) flo.Middleware {
+		return func(ctx context.Context, info flo.StepInfo, input interface{}, next flo.Handler) (interface{}, error) {
+			order = append(order, name)
+			return next(ctx, input.(string)+name)
+		}
+	}
+	c := &collector{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithMiddleware(named("1"), named("2"))).
+		Add(middle, flo.WithStepMiddleware(named("3"))).
+		Add(c.collect, flo.WithStepMiddleware()).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := strings.Join(order, ","); got != "1,2,3,1,2" {
+		t.Errorf("got %s, want 1,2,3,1,2", got)
+	}
+	if got := c.sorted(); got != "A12312" {
+		t.Errorf("got %s, want A12312", got)
+	}
+}
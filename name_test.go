@@ -0,0 +1,76 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+func TestStepNameValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       *flo.Builder
+		wantErr string
+	}{
+		{"default names may repeat", flo.NewBuilder().Add(startInt).Add(addInts).Add(addInts).Add(func(ctx context.Context, i int) error { return nil }), ""},
+		{"explicit names", flo.NewBuilder().Add(start, flo.WithStepName("a")).Add(middle, flo.WithStepName("b")).Add(end), ""},
+		{"duplicate names", flo.NewBuilder().Add(start, flo.WithStepName("a")).Add(middle, flo.WithStepName("a")).Add(end), `Step 2: step name "a"`},
+		{"names in type errors", flo.NewBuilder().Add(start).Add(square), "Step 2 (square)"},
+		{"names in position errors", flo.NewBuilder().Add(start).Add(end, flo.WithStepName("sink")).Add(end), "Step 2 (sink)"},
+		{"names in branch errors", flo.NewBuilder().Add(start).Broadcast(flo.NewBuilder().Add(square)), "Step 2 (broadcast): branch 1: Step 1 (square)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.b.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an error starting with %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStepNameRuntime(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "test"
+	close(inCh)
+	var got *flo.StepError
+	dl := &deadLetters{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithDeadLetterHandler(dl.handle)).
+		Add(erroringMiddle, flo.WithStepName("fail"), flo.WithStepErrorHandler(func(err error) {
+			errors.As(err, &got)
+		})).
+		Add(end).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got == nil || got.Name != "fail" || !strings.HasPrefix(got.Error(), "step 1 (fail): ") {
+		t.Errorf("got %v, want an error from the step named fail", got)
+	}
+	if len(dl.letters) != 1 || dl.letters[0].Name != "fail" {
+		t.Errorf("got %v, want a dead letter from the step named fail", dl.letters)
+	}
+}
+
+func TestStepNameStats(t *testing.T) {
+	stats := flo.NewBuilder().Add(start).Add(middle, flo.WithStepName("upper")).Add(end).Stats()
+
+	var names []string
+	for _, st := range stats.Steps {
+		names = append(names, st.Name)
+	}
+	if got := strings.Join(names, ","); got != "start,upper,end" {
+		t.Errorf("got %s, want start,upper,end", got)
+	}
+}
@@ -0,0 +1,157 @@
+package flo
+
+import (
+	"context"
+	"sync"
+)
+
+// WithOrdering configures the default ordering window for each step. When a step has an ordering window it sends
+// data along in the same order it was received, even when it is run by more than one worker. The window is the number
+// of pieces of data that may be in flight, or waiting on an earlier piece of data to finish, at any one time. While
+// waiting, a piece of data holds onto at most one result, so steps that produce more, such as emitters and flat maps,
+// block until it is their turn. A window of zero or less, the default, means data is sent along as soon as it is
+// processed.
+//
+// To keep a flo ordered end to end, its first step should produce data in order. For instance, it could be fed by a
+// single input channel or be a source run with a parallelism of one.
+func WithOrdering(window int) Option {
+	return func(b *Builder) {
+		if window < 0 {
+			window = 0
+		}
+		b.window = window
+	}
+}
+
+// WithStepOrdering configures the ordering window for a step, see WithOrdering for details. A window of zero disables
+// ordering for a step, even if a default was configured for the flo. Ordering has no effect on sources, batches and
+// steps that consume data.
+func WithStepOrdering(window int) StepOption {
+	return func(s *stepRunner) {
+		if window < 0 {
+			window = 0
+		}
+		s.window = window
+	}
+}
+
+// ordered reports whether the step should send data along in the same order it was received.
+func (s *stepRunner) ordered() bool {
+	if s.window < 1 {
+		return false
+	}
+	switch s.sType {
+	case inOut, filter, flatMap, emitter, fanOut, route:
+		return true
+	}
+	return false
+}
+
+// orderedItem is a piece of data being processed by an ordered step.
+type orderedItem struct {
+	input interface{}
+	// turn is closed once everything produced for earlier items has been sent along. From then on, whatever is
+	// produced for the item is sent straight through.
+	turn chan struct{}
+	// done receives whatever was produced for the input before its turn, once it has been processed.
+	done chan []delivery
+
+	buffered []delivery
+	head     bool
+}
+
+// send passes along data produced for the item. Before its turn the item holds onto a single piece of data, which is
+// all most steps produce, and then blocks until its turn. That way emitters and flat maps are still held back by the
+// next step, rather than buffering everything they produce.
+func (item *orderedItem) send(ctx context.Context, ch chan interface{}, value interface{}) bool {
+	if !item.head {
+		select {
+		case <-item.turn:
+		default:
+			if len(item.buffered) == 0 {
+				item.buffered = append(item.buffered, delivery{ch: ch, value: value})
+				return true
+			}
+			select {
+			case <-item.turn:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		item.head = true
+		buffered := item.buffered
+		item.buffered = nil
+		for _, d := range buffered {
+			if !send(ctx, d.ch, d.value) {
+				return false
+			}
+		}
+	}
+	return send(ctx, ch, value)
+}
+
+// delivery is a piece of data to be sent along to a channel.
+type delivery struct {
+	ch    chan interface{}
+	value interface{}
+}
+
+// processOrdered handles data with a pool of workers, while making sure whatever is produced for each piece of data is
+// sent along in the order the data was received. Items are sequenced as they are received, and a reorder buffer of
+// at most window items holds onto results until every earlier item has been sent along. The item at the head of the
+// buffer sends its results straight through.
+func (s *stepRunner) processOrdered(ctx context.Context, fn itemFn) {
+	var (
+		work    = make(chan *orderedItem)
+		pending = make(chan *orderedItem, s.window)
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < s.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				fn(ctx, item.input, item.send)
+				item.done <- item.buffered
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for item := range pending {
+			close(item.turn)
+			select {
+			case deliveries := <-item.done:
+				for _, d := range deliveries {
+					send(ctx, d.ch, d.value)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		input, ok := receive(ctx, s.inCh)
+		if !ok {
+			break
+		}
+		item := &orderedItem{input: input, turn: make(chan struct{}), done: make(chan []delivery, 1)}
+		// blocks once the window is full, until the oldest item has been sent along
+		select {
+		case pending <- item:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		// workers only block on sending along their results until the flo is aborted, so this is safe even then
+		work <- item
+	}
+	close(work)
+	close(pending)
+	wg.Wait()
+}
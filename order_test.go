@@ -0,0 +1,126 @@
+package flo_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestOrdering(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []flo.Option
+		step    flo.StepOption
+	}{
+		{"flo wide", []flo.Option{flo.WithOrdering(3)}, flo.WithStepParallelism(5)},
+		{"per step", nil, flo.WithStepOrdering(3)},
+		{"window of one", nil, flo.WithStepOrdering(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inCh := make(chan int, 20)
+			for i := 0; i < 20; i++ {
+				inCh <- i
+			}
+			close(inCh)
+			outCh := make(chan int, 20)
+
+			options := append(tt.options, flo.WithInput(inCh), flo.WithOutput(outCh), flo.WithParallelism(5))
+			err := flo.NewBuilder(options...).
+				Add(jitter, tt.step).
+				Filter(isEven, tt.step).
+				Add(jitter, tt.step).
+				BuildAndExecute(context.Background())
+			if err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+
+			for want := 0; want < 20; want += 2 {
+				if got := <-outCh; got != want {
+					t.Fatalf("got %d, want %d", got, want)
+				}
+			}
+			close(outCh)
+		})
+	}
+}
+
+func TestOrderingFlatMap(t *testing.T) {
+	inCh := make(chan string, 3)
+	inCh <- "a b"
+	inCh <- "c"
+	inCh <- "d e f"
+	close(inCh)
+	outCh := make(chan string, 6)
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithOutput(outCh), flo.WithParallelism(3), flo.WithOrdering(2)).
+		FlatMap(func(ctx context.Context, s string) ([]string, error) {
+			time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+			return words(ctx, s)
+		}).
+		Add(emitWords).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	var got []string
+	for i := 0; i < 6; i++ {
+		got = append(got, <-outCh)
+	}
+	close(outCh)
+	if fmt.Sprint(got) != "[a b c d e f]" {
+		t.Fatalf("got %v, want [a b c d e f]", got)
+	}
+}
+
+func TestOrderingEmitterBackpressure(t *testing.T) {
+	inCh := make(chan string, 2)
+	inCh <- "a"
+	inCh <- "b"
+	close(inCh)
+	release := make(chan struct{})
+	var emitted int32
+
+	done := make(chan error, 1)
+	go func() {
+		done <- flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(2), flo.WithOrdering(2)).
+			Add(func(ctx context.Context, s string, emit func(string) error) error {
+				for i := 0; i < 1000; i++ {
+					if err := emit(s); err != nil {
+						return err
+					}
+					atomic.AddInt32(&emitted, 1)
+				}
+				return nil
+			}).
+			Add(func(ctx context.Context, s string) error {
+				<-release
+				return nil
+			}, flo.WithStepParallelism(1)).
+			BuildAndExecute(context.Background())
+	}()
+
+	// the next step is not taking in any data, so both items should soon be blocked in emit
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&emitted); got > 10 {
+		t.Errorf("got %d emitted, want emit to block once the next step backs up", got)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got := atomic.LoadInt32(&emitted); got != 2000 {
+		t.Errorf("got %d emitted, want 2000", got)
+	}
+}
+
+func jitter(ctx context.Context, i int) (int, error) {
+	time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+	return i, nil
+}
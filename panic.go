@@ -0,0 +1,53 @@
+package flo
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error reported when a step panics. By default a panic in a step is recovered and reported to the
+// error handler, the worker that called the step carries on processing data.
+type PanicError struct {
+	// Value is the value the step panicked with.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("step panicked: %v\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the value the step panicked with if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithRepanic configures the flo to not recover from panics in its steps, crashing the program as it would if the
+// step were called directly. This may be useful in tests.
+func WithRepanic() Option {
+	return func(b *Builder) {
+		b.repanic = true
+	}
+}
+
+// WithStepRepanic configures a step to not recover from panics, see WithRepanic for details.
+func WithStepRepanic() StepOption {
+	return func(s *stepRunner) {
+		s.repanic = true
+	}
+}
+
+// recoverCall returns a callFn that converts any panic in fn into a PanicError.
+func recoverCall(fn callFn) callFn {
+	return func(ctx context.Context, in interface{}) (value interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return fn(ctx, in)
+	}
+}
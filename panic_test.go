@@ -0,0 +1,82 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+func TestPanicRecovered(t *testing.T) {
+	inCh := make(chan string, 3)
+	inCh <- "a"
+	inCh <- "panic"
+	inCh <- "b"
+	close(inCh)
+	c := &collector{}
+	var errs []error
+	var mu sync.Mutex
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(1), flo.WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	})).
+		Add(panicky).
+		Add(c.collect).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "A,B" {
+		t.Errorf("got %s, want A,B", got)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+	var pe *flo.PanicError
+	if !errors.As(errs[0], &pe) {
+		t.Fatalf("got %T, want *flo.PanicError", errs[0])
+	}
+	if pe.Value != "boom" {
+		t.Errorf("got %v, want boom", pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "panicky") {
+		t.Errorf("got stack %s, want it to contain panicky", pe.Stack)
+	}
+}
+
+func TestPanicRetried(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "a"
+	close(inCh)
+	c := &collector{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh)).
+		Add(func(ctx context.Context, s string) (string, error) {
+			if flo.Attempt(ctx) == 1 {
+				panic("boom")
+			}
+			return middle(ctx, s)
+		}, flo.WithStepRetry(flo.RetryPolicy{MaxAttempts: 2, InitialBackoff: 1})).
+		Add(c.collect).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "A" {
+		t.Errorf("got %s, want A", got)
+	}
+}
+
+func panicky(ctx context.Context, s string) (string, error) {
+	if s == "panic" {
+		panic("boom")
+	}
+	return middle(ctx, s)
+}
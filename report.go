@@ -0,0 +1,102 @@
+package flo
+
+import (
+	"context"
+	"time"
+)
+
+// maxErrorSamples is the number of errors kept for each step's report.
+const maxErrorSamples = 10
+
+// Report summarizes a run of a flo.
+type Report struct {
+	// Elapsed is how long the flo ran for.
+	Elapsed time.Duration
+	// Steps holds the report of each step, in the order they were registered.
+	Steps []StepReport
+}
+
+// StepReport summarizes what a step did during a run of a flo.
+type StepReport struct {
+	// Step is the position, starting at one, of the step within the Builder it was registered with.
+	Step int
+	// Name is the name of the step, see WithStepName.
+	Name string
+	// In is the number of pieces of data the step took in.
+	In int64
+	// Out is the number of pieces of data the step sent along.
+	Out int64
+	// Errors is the number of times the step returned an error.
+	Errors int64
+	// ErrorSamples holds the first few errors returned by the step.
+	ErrorSamples []error
+	// Dropped is the number of pieces of data the step chose not to send along.
+	Dropped int64
+	// Busy is the total time spent in calls to the step, across all of its workers.
+	Busy time.Duration
+	// Branches holds the reports of the steps of the flos fed by a broadcast or route, or the producers of a merge,
+	// keyed the same way as StepStats.Branches.
+	Branches map[string][]StepReport
+}
+
+// ExecuteWithReport is the same as BuildAndExecute, but also returns a report of what each step of the flo did. The
+// report is nil if the flo fails validation.
+//
+//  report, err := flo.NewBuilder(flo.WithInput(ch)).
+//                     Add(parse).
+//                     Add(store).
+//                     ExecuteWithReport(ctx)
+//  for _, st := range report.Steps {
+//      log.Printf("%s: %d in, %d errors, busy for %v", st.Name, st.In, st.Errors, st.Busy)
+//  }
+func (b *Builder) ExecuteWithReport(ctx context.Context) (*Report, error) {
+	begin := time.Now()
+	f, err := b.Start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = f.Wait()
+	return &Report{Elapsed: time.Since(begin), Steps: b.report(b.Stats())}, err
+}
+
+// report returns the report of each step of the flo, based on its stats.
+func (b *Builder) report(stats Stats) []StepReport {
+	reports := make([]StepReport, len(b.steps))
+	for i, st := range stats.Steps {
+		reports[i] = StepReport{
+			Step:         st.Step,
+			Name:         st.Name,
+			In:           st.In,
+			Out:          st.Out,
+			Errors:       st.Errored,
+			ErrorSamples: b.steps[i].metrics.errorSamples(),
+			Dropped:      st.Dropped,
+			Busy:         st.Latency.Sum,
+		}
+		if len(st.Branches) == 0 {
+			continue
+		}
+		branches := b.steps[i].branches()
+		reports[i].Branches = make(map[string][]StepReport, len(st.Branches))
+		for key, branchStats := range st.Branches {
+			reports[i].Branches[key] = branches[key].report(branchStats)
+		}
+	}
+	return reports
+}
+
+// sample keeps err if the step has not returned too many errors already.
+func (m *stepMetrics) sample(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.samples) < maxErrorSamples {
+		m.samples = append(m.samples, err)
+	}
+}
+
+// errorSamples returns the errors kept by sample.
+func (m *stepMetrics) errorSamples() []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]error(nil), m.samples...)
+}
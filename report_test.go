@@ -0,0 +1,79 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestExecuteWithReport(t *testing.T) {
+	inCh := make(chan int, 30)
+	for i := 0; i < 30; i++ {
+		inCh <- i
+	}
+	close(inCh)
+
+	report, err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(3)).
+		Add(func(ctx context.Context, i int) (int, error) {
+			time.Sleep(time.Millisecond)
+			if i%2 == 1 {
+				return 0, fmt.Errorf("odd %d", i)
+			}
+			return i, nil
+		}, flo.WithStepName("evens")).
+		Route(func(ctx context.Context, i int) (int, error) { return i % 3, nil }, map[interface{}]*flo.Builder{
+			0: flo.NewBuilder().Add(func(ctx context.Context, i int) error { return nil }),
+		}).
+		ExecuteWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if report.Elapsed <= 0 {
+		t.Errorf("got %v, want elapsed time", report.Elapsed)
+	}
+	if len(report.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(report.Steps))
+	}
+	evens := report.Steps[0]
+	if evens.Name != "evens" || evens.In != 30 || evens.Out != 15 || evens.Errors != 15 {
+		t.Errorf("got %s with %d/%d/%d, want evens with 30/15/15", evens.Name, evens.In, evens.Out, evens.Errors)
+	}
+	if len(evens.ErrorSamples) != 10 {
+		t.Errorf("got %d error samples, want 10", len(evens.ErrorSamples))
+	}
+	var se *flo.StepError
+	if !errors.As(evens.ErrorSamples[0], &se) || se.Name != "evens" {
+		t.Errorf("got %v, want a *flo.StepError from evens", evens.ErrorSamples[0])
+	}
+	if evens.Busy < 30*time.Millisecond {
+		t.Errorf("got %v, want at least 30ms busy", evens.Busy)
+	}
+
+	route := report.Steps[1]
+	if route.In != 15 || route.Out != 5 || route.Dropped != 10 {
+		t.Errorf("got %d/%d/%d, want 15/5/10", route.In, route.Out, route.Dropped)
+	}
+	if branch := route.Branches["0"]; len(branch) != 1 || branch[0].In != 5 {
+		t.Errorf("got %+v, want a branch that took in 5", branch)
+	}
+}
+
+func TestExecuteWithReportFailures(t *testing.T) {
+	report, err := flo.NewBuilder().Add(start).ExecuteWithReport(context.Background())
+	if err == nil || report != nil {
+		t.Errorf("got %v and %v, want a validation error and no report", report, err)
+	}
+
+	report, err = flo.NewBuilder(flo.WithFailFast()).
+		Add(start).
+		Add(func(ctx context.Context, s string) error { return errFatal }).
+		ExecuteWithReport(context.Background())
+	if !errors.Is(err, errFatal) || report == nil || report.Steps[1].Errors < 1 {
+		t.Errorf("got %v and %v, want %v and a report", report, err, errFatal)
+	}
+}
@@ -0,0 +1,106 @@
+package flo
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures how a step is retried when it returns an error. The zero value of each field, other than
+// MaxAttempts, picks a sensible default.
+type RetryPolicy struct {
+	// MaxAttempts is the most number of times a step is called for a single piece of data, including the first call.
+	// A value of one or less disables retries.
+	MaxAttempts int
+	// InitialBackoff is how long to wait before the first retry. Defaults to 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps how long to wait between retries. Defaults to no cap.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the backoff grows by after each retry. Defaults to 2.
+	Multiplier float64
+	// Jitter is the fraction of each backoff, between 0 and 1, that is randomized. For example, a Jitter of 0.2 with a
+	// backoff of 1s will wait somewhere between 800ms and 1s. Defaults to no jitter.
+	Jitter float64
+	// Retryable reports whether an error should be retried. Defaults to retrying every error.
+	Retryable func(error) bool
+}
+
+// WithStepRetry configures a step to be retried when it returns an error. Waits between retries are cut short should
+// the flo's context be canceled. The error handler is only called with the error returned by the final attempt, and a
+// step may find out which attempt it is on with Attempt. Retries have no effect on batches. Note that an emitter that
+// is retried may send along the same data more than once.
+//
+//  err := flo.NewBuilder(flo.WithInput(ch)).
+//             Add(fetch, flo.WithStepRetry(flo.RetryPolicy{MaxAttempts: 3, Jitter: 0.2})).
+//             Add(store).
+//             BuildAndExecute(ctx)
+func WithStepRetry(policy RetryPolicy) StepOption {
+	return func(s *stepRunner) {
+		if policy.MaxAttempts < 2 {
+			s.retry = nil
+			return
+		}
+		s.retry = &policy
+	}
+}
+
+type attemptKey struct{}
+
+// Attempt returns which attempt, starting at one, a step is on for the piece of data it is currently processing. It
+// should be called with the context passed to the step. Steps that are not retried are always on their first attempt.
+func Attempt(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+// wrap returns a callFn that calls fn until it succeeds, returns an error that should not be retried, or runs out of
+// attempts.
+func (p *RetryPolicy) wrap(fn callFn) callFn {
+	return func(ctx context.Context, in interface{}) (interface{}, error) {
+		for attempt := 1; ; attempt++ {
+			value, err := fn(context.WithValue(ctx, attemptKey{}, attempt), in)
+			if err == nil || attempt >= p.MaxAttempts || (p.Retryable != nil && !p.Retryable(err)) {
+				return value, err
+			}
+
+			t := time.NewTimer(p.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return value, err
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// backoff returns how long to wait after the given attempt fails.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	if d <= 0 {
+		d = float64(100 * time.Millisecond)
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	for i := 1; i < attempt; i++ {
+		d *= multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,114 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       flo.RetryPolicy
+		wantAttempts int32
+		wantErrs     int
+		wantOut      string
+	}{
+		{"succeeds", flo.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, Jitter: 0.5}, 3, 0, "A"},
+		{"exhausted", flo.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}, 2, 1, ""},
+		{"not retryable", flo.RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+			Retryable:      func(err error) bool { return !errors.Is(err, errFlaky) },
+		}, 1, 1, ""},
+		{"disabled", flo.RetryPolicy{MaxAttempts: 1}, 1, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inCh := make(chan string, 1)
+			inCh <- "a"
+			close(inCh)
+			c := &collector{}
+			var attempts int32
+			var errs []error
+			var mu sync.Mutex
+
+			err := flo.NewBuilder(flo.WithInput(inCh)).
+				Add(func(ctx context.Context, s string) (string, error) {
+					atomic.AddInt32(&attempts, 1)
+					if flo.Attempt(ctx) < 3 {
+						return "", errFlaky
+					}
+					return middle(ctx, s)
+				}, flo.WithStepRetry(tt.policy), flo.WithStepErrorHandler(func(err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					errs = append(errs, err)
+				})).
+				Add(c.collect).
+				BuildAndExecute(context.Background())
+			if err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("got %d attempts, want %d", got, tt.wantAttempts)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Errorf("got %d errors, want %d", len(errs), tt.wantErrs)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, errFlaky) {
+					t.Errorf("got %v, want %v", err, errFlaky)
+				}
+			}
+			if got := c.sorted(); got != tt.wantOut {
+				t.Errorf("got %q, want %q", got, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "a"
+	close(inCh)
+	ctx, cancel := context.WithCancel(context.Background())
+	eh := &errHandle{}
+
+	done := make(chan error)
+	go func() {
+		done <- flo.NewBuilder(flo.WithInput(inCh)).
+			Add(func(ctx context.Context, s string) (string, error) {
+				cancel()
+				return "", errFlaky
+			}, flo.WithStepRetry(flo.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}), flo.WithStepErrorHandler(eh.handleError)).
+			Add(end).
+			BuildAndExecute(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry did not stop waiting when the context was canceled")
+	}
+	if !eh.hasHandled {
+		t.Errorf("got false, want true")
+	}
+}
+
+func TestAttempt(t *testing.T) {
+	if got := flo.Attempt(context.Background()); got != 1 {
+		t.Errorf("got %d, want 1", got)
+	}
+}
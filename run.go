@@ -0,0 +1,157 @@
+package flo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Flo is a handle to a running flo, returned by Builder.Start.
+type Flo struct {
+	b      *Builder
+	stop   context.CancelFunc
+	kill   context.CancelFunc
+	failer *failer
+	done   chan struct{}
+	err    error
+	// drain ensures the drain timeout is only started once.
+	drain sync.Once
+}
+
+type (
+	intakeKey struct{}
+	stepKey   struct{}
+)
+
+// Start validates the flo and starts it processing data, without waiting for it to shut down. If validation fails an
+// error is returned and no data will be processed. The flo runs until the provided context is canceled, its input
+// channels are closed, or it is stopped with the returned handle. What happens once the context is canceled is
+// configured WithShutdown.
+//
+//  f, err := flo.NewBuilder(flo.WithInput(ch)).
+//                Add(parse).
+//                Add(store).
+//                Start(ctx)
+//  if err != nil {
+//      return err
+//  }
+//  defer f.Stop()
+func (b *Builder) Start(ctx context.Context) (*Flo, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	f := &Flo{b: b, done: make(chan struct{})}
+	parent := ctx
+	if b.shutdown == ShutdownDrain {
+		ctx = detachedContext{parent: ctx}
+	}
+	ctx, f.kill = context.WithCancel(ctx)
+	if b.failFast != nil {
+		ctx, f.failer = withFailer(ctx, b.failFast)
+	}
+	if b.shutdown == ShutdownAbort {
+		var intake context.Context
+		intake, f.stop = context.WithCancel(ctx)
+		ctx = context.WithValue(ctx, intakeKey{}, intake)
+		b.launch(ctx)
+		go f.await()
+		return f, nil
+	}
+
+	// a draining flo keeps moving data along until it is aborted, while its steps are called with a context that is
+	// canceled along with the one the flo was started with
+	intake, stop := context.WithCancel(parent)
+	steps, cancelSteps := context.WithCancel(parent)
+	f.stop = stop
+	ctx = context.WithValue(ctx, intakeKey{}, intake)
+	ctx = context.WithValue(ctx, stepKey{}, steps)
+	b.launch(ctx)
+	go f.await()
+	go func() {
+		select {
+		case <-parent.Done():
+			f.Stop()
+		case <-ctx.Done():
+			stop()
+			cancelSteps()
+		case <-f.done:
+			cancelSteps()
+		}
+	}()
+	return f, nil
+}
+
+// await waits for the flo to shut down before releasing it.
+func (f *Flo) await() {
+	f.b.awaitShutdown()
+	f.stop()
+	f.kill()
+	if f.failer != nil {
+		f.err = f.failer.err()
+	}
+	close(f.done)
+}
+
+// Wait blocks until the flo shuts down. If the flo was configured WithFailFast, the error that stopped it is returned.
+func (f *Flo) Wait() error {
+	<-f.done
+	return f.err
+}
+
+// Done returns a channel that is closed once the flo shuts down.
+func (f *Flo) Done() <-chan struct{} {
+	return f.done
+}
+
+// Stop gracefully shuts down the flo. Sources stop being called, the context passed to a source that is waiting on data
+// is canceled, and input channels stop being read from, while data that has already been taken in continues to be
+// processed. If the flo was configured WithDrainTimeout, it is killed should it not shut down in time. Stop does not
+// wait for the flo to shut down, use Wait or Done for that.
+func (f *Flo) Stop() {
+	f.stop()
+	if timeout := f.b.drainTimeout; timeout > 0 {
+		f.drain.Do(func() {
+			go f.killAfter(timeout)
+		})
+	}
+}
+
+// Kill shuts down the flo immediately by canceling the context passed to its steps. Data that has been taken in but not
+// yet processed is dropped, and calls to steps that timed out, see WithStepTimeout, are no longer waited on. Kill does
+// not wait for the flo to shut down, use Wait or Done for that.
+func (f *Flo) Kill() {
+	f.kill()
+}
+
+// killAfter kills the flo should it not shut down within d.
+func (f *Flo) killAfter(d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		f.kill()
+	case <-f.done:
+	}
+}
+
+// Stats returns a snapshot of what each step of the flo is doing, see Builder.Stats.
+func (f *Flo) Stats() Stats {
+	return f.b.Stats()
+}
+
+// stepContext returns the context steps are called with, which is canceled once the flo should stop processing data.
+func stepContext(ctx context.Context) context.Context {
+	if steps, ok := ctx.Value(stepKey{}).(context.Context); ok {
+		return steps
+	}
+	return ctx
+}
+
+// intakeContext returns the context that is canceled once the flo should stop taking in data.
+func intakeContext(ctx context.Context) context.Context {
+	if intake, ok := ctx.Value(intakeKey{}).(context.Context); ok {
+		return intake
+	}
+	return ctx
+}
@@ -0,0 +1,129 @@
+package flo_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestStartStop(t *testing.T) {
+	var produced, consumed, canceled int32
+	f, err := flo.NewBuilder(flo.WithParallelism(2)).
+		Add(func(ctx context.Context) (int, error) {
+			atomic.AddInt32(&produced, 1)
+			time.Sleep(time.Millisecond)
+			return 1, nil
+		}).
+		Add(func(ctx context.Context, i int) (int, error) {
+			time.Sleep(2 * time.Millisecond)
+			return i, nil
+		}).
+		Add(func(ctx context.Context, i int) error {
+			if ctx.Err() != nil {
+				atomic.AddInt32(&canceled, 1)
+			}
+			atomic.AddInt32(&consumed, 1)
+			return nil
+		}).
+		Start(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-f.Done():
+		t.Fatal("got done, want the flo to be running")
+	default:
+	}
+	if got := f.Stats().Steps[0].Out; got == 0 {
+		t.Errorf("got %d, want the source to have produced data", got)
+	}
+
+	f.Stop()
+	if err := f.Wait(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	<-f.Done()
+
+	// every piece of data taken in before the flo was stopped is processed, without its context being canceled
+	if p, c := atomic.LoadInt32(&produced), atomic.LoadInt32(&consumed); p != c {
+		t.Errorf("got %d produced and %d consumed, want them to match", p, c)
+	}
+	if got := atomic.LoadInt32(&canceled); got != 0 {
+		t.Errorf("got %d canceled, want 0", got)
+	}
+}
+
+func TestStartStopBlockedSource(t *testing.T) {
+	eh := &errHandle{}
+	f, err := flo.NewBuilder(flo.WithErrorHandler(eh.handleError)).
+		Add(func(ctx context.Context) (string, error) {
+			// a long poll that only returns once its context is canceled
+			<-ctx.Done()
+			return "", ctx.Err()
+		}).
+		Add(end).
+		Start(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	f.Stop()
+	select {
+	case <-f.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("flo did not shut down after being stopped")
+	}
+	if eh.hasHandled {
+		t.Errorf("got true, want the interrupted source not to be reported")
+	}
+}
+
+func TestStartStopInputChannel(t *testing.T) {
+	inCh := make(chan string)
+	c := &collector{}
+	f, err := flo.NewBuilder(flo.WithInput(inCh)).Add(middle).Add(c.collect).Start(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	inCh <- "a"
+	f.Stop()
+	if err := f.Wait(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got := c.sorted(); got != "A" {
+		t.Errorf("got %s, want A", got)
+	}
+}
+
+func TestStartKill(t *testing.T) {
+	f, err := flo.NewBuilder().
+		Add(start).
+		Add(func(ctx context.Context, s string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		Start(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	f.Kill()
+	select {
+	case <-f.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("flo did not shut down after being killed")
+	}
+}
+
+func TestStartValidates(t *testing.T) {
+	f, err := flo.NewBuilder().Add(start).Start(context.Background())
+	if err == nil || f != nil {
+		t.Errorf("got %v and %v, want a validation error and no flo", f, err)
+	}
+}
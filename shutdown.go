@@ -0,0 +1,64 @@
+package flo
+
+import (
+	"context"
+	"time"
+)
+
+// ShutdownMode configures what a flo does once the context it was started with is canceled.
+type ShutdownMode int
+
+const (
+	// ShutdownDrain stops the flo taking in data, as if Flo.Stop was called, while everything that has already been taken
+	// in is still sent through the flo. The context passed to the flo's steps is canceled along with the one the flo was
+	// started with, so they may choose to give up on the data left to process. This is the default.
+	ShutdownDrain ShutdownMode = iota
+	// ShutdownAbort cancels the context passed to the flo's steps right away. Data that has been taken in but not yet
+	// processed is dropped, which includes data already read from the flo's input channels and any partial batch.
+	ShutdownAbort
+)
+
+// WithShutdown configures what the flo does once the context it was started with is canceled. This has no effect on a
+// Builder used as a branch or producer.
+//
+//  err := flo.NewBuilder(flo.WithInput(ch), flo.WithShutdown(flo.ShutdownAbort)).
+//             Add(parse).
+//             Add(store).
+//             BuildAndExecute(ctx)
+func WithShutdown(mode ShutdownMode) Option {
+	return func(b *Builder) {
+		b.shutdown = mode
+	}
+}
+
+// WithDrainTimeout configures how long the flo may take to drain, either because it was stopped with Flo.Stop or
+// because its context was canceled while configured with ShutdownDrain. Should the flo not shut down in time it falls
+// back to aborting, as if Flo.Kill was called. A timeout of zero or less, the default, waits for the flo to drain no
+// matter how long it takes.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(b *Builder) {
+		b.drainTimeout = d
+	}
+}
+
+// detachedContext carries the values of its parent, but is not canceled along with it. It lets a draining flo keep
+// sending along data once the context it was started with is canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
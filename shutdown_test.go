@@ -0,0 +1,175 @@
+package flo_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestShutdownDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	inCh := make(chan int)
+	var consumed int32
+
+	f, err := flo.NewBuilder(flo.WithInput(inCh), flo.WithShutdown(flo.ShutdownDrain), flo.WithParallelism(2)).
+		Add(func(ctx context.Context, i int) (int, error) {
+			time.Sleep(5 * time.Millisecond)
+			return i, nil
+		}).
+		Add(func(ctx context.Context, i int) error {
+			atomic.AddInt32(&consumed, 1)
+			return nil
+		}).
+		Start(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		inCh <- i
+	}
+	cancel()
+	if err := f.Wait(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := atomic.LoadInt32(&consumed); got != 4 {
+		t.Errorf("got %d consumed, want 4", got)
+	}
+}
+
+func TestShutdownDrainBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var produced, consumed int32
+
+	err := flo.NewBuilder().
+		Add(func(ctx context.Context) (string, error) {
+			if atomic.AddInt32(&produced, 1) == 3 {
+				cancel()
+			}
+			return "a", nil
+		}).
+		Batch(100, time.Hour).
+		Add(func(ctx context.Context, s []string) error {
+			atomic.AddInt32(&consumed, int32(len(s)))
+			return nil
+		}).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := atomic.LoadInt32(&consumed); got != 3 {
+		t.Errorf("got %d consumed, want 3", got)
+	}
+}
+
+func TestShutdownDrainBlockedSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f, err := flo.NewBuilder(flo.WithShutdown(flo.ShutdownDrain)).
+		Add(func(ctx context.Context) (string, error) {
+			// a long poll that only returns once its context is canceled
+			<-ctx.Done()
+			return "", ctx.Err()
+		}).
+		Add(end).
+		Start(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	cancel()
+	select {
+	case <-f.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("flo did not shut down after draining")
+	}
+}
+
+func TestShutdownDrainTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// nothing reads from the output channel, so the flo can not drain
+	outCh := make(chan string)
+
+	f, err := flo.NewBuilder(flo.WithOutput(outCh), flo.WithDrainTimeout(10*time.Millisecond)).
+		Add(start).
+		Add(middle).
+		Start(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-f.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("flo did not shut down after its drain timed out")
+	}
+}
+
+func TestStopDrainTimeout(t *testing.T) {
+	f, err := flo.NewBuilder(flo.WithDrainTimeout(10 * time.Millisecond)).
+		Add(start).
+		Add(func(ctx context.Context, s string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		Start(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	f.Stop()
+	select {
+	case <-f.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("flo did not shut down after its drain timed out")
+	}
+}
+
+func TestShutdownAbort(t *testing.T) {
+	tests := []struct {
+		name string
+		b    func(options ...flo.Option) *flo.Builder
+	}{
+		{"in out", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).Add(start).Add(middle).Add(middle)
+		}},
+		{"ordered", func(options ...flo.Option) *flo.Builder {
+			options = append(options, flo.WithOrdering(2), flo.WithParallelism(2))
+			return flo.NewBuilder(options...).Add(start).Add(middle).Add(middle)
+		}},
+		{"batch", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).Add(start).Batch(2, 0).Add(func(ctx context.Context, s []string) (string, error) {
+				return s[0], nil
+			})
+		}},
+		{"merge", func(options ...flo.Option) *flo.Builder {
+			return flo.NewBuilder(options...).Merge(start, start).Add(middle)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			// nothing reads from the output channel, so the flo backs up until it is aborted
+			outCh := make(chan string)
+
+			f, err := tt.b(flo.WithOutput(outCh), flo.WithShutdown(flo.ShutdownAbort)).Start(ctx)
+			if err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			select {
+			case <-f.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("flo did not shut down after being aborted")
+			}
+		})
+	}
+}
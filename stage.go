@@ -0,0 +1,113 @@
+package flo
+
+import (
+	"context"
+)
+
+// Source is a type safe Step that produces data. It may only be used as the first step of a flo.
+type Source[R any] func(context.Context) (R, error)
+
+// Stage is a type safe Step that transforms a T into an R. It may be used at any position in the flo.
+type Stage[T, R any] func(context.Context, T) (R, error)
+
+// Emitter is a type safe Step that transforms a T into any number of Rs, sending each one along by calling emit. It may
+// be used at any position in the flo.
+type Emitter[T, R any] func(ctx context.Context, in T, emit func(R) error) error
+
+// Sink is a type safe Step that consumes data. It may only be used as the last step of a flo.
+type Sink[T any] func(context.Context, T) error
+
+func (s Source[R]) invoke(ctx context.Context, _ interface{}) (interface{}, error) {
+	return s(ctx)
+}
+
+func (s Stage[T, R]) invoke(ctx context.Context, in interface{}) (interface{}, error) {
+	v, _ := in.(T)
+	return s(ctx, v)
+}
+
+func (s Emitter[T, R]) invoke(ctx context.Context, in interface{}) (interface{}, error) {
+	v, _ := in.(T)
+	emit, _ := ctx.Value(emitKey{}).(func(context.Context, interface{}) error)
+	return nil, s(ctx, v, func(r R) error {
+		return emit(ctx, r)
+	})
+}
+
+func (s Sink[T]) invoke(ctx context.Context, in interface{}) (interface{}, error) {
+	v, _ := in.(T)
+	return nil, s(ctx, v)
+}
+
+// Chain is a type safe view of a Builder whose last registered step outputs an R. Steps appended with Then and End are
+// checked against R by the compiler rather than by Validate. A Chain shares its underlying Builder, so options such as
+// WithParallelism and WithErrorHandler still apply.
+//
+// A Chain is started with either Begin, for flos that produce their own data, or Input, for flos fed by a channel:
+//  b := flo.NewBuilder()
+//  c := flo.Begin(b, produce)      // func(context.Context) (int, error)
+//  s := flo.Then(c, format)        // func(context.Context, int) (string, error)
+//  err := flo.End(s, print).       // func(context.Context, string) error
+//             BuildAndExecute(ctx)
+type Chain[R any] struct {
+	b *Builder
+}
+
+// Begin registers a Source as the first step of the Builder.
+func Begin[R any](b *Builder, s Source[R], options ...StepOption) Chain[R] {
+	b.Add(s, options...)
+	return Chain[R]{b: b}
+}
+
+// Input registers ch as an input channel of the Builder. It is the type safe equivalent of WithInput. Items are
+// received from ch without the use of reflection.
+func Input[T any](b *Builder, ch <-chan T) Chain[T] {
+	b.inChs = append(b.inChs, &inputChannel{
+		ch: ch,
+		recv: func(ctx context.Context) (interface{}, bool) {
+			select {
+			case <-ctx.Done():
+				return nil, false
+			case v, ok := <-ch:
+				return v, ok
+			}
+		},
+	})
+	return Chain[T]{b: b}
+}
+
+// Then registers a Stage that consumes the output of the previous step.
+func Then[T, R any](c Chain[T], s Stage[T, R], options ...StepOption) Chain[R] {
+	c.b.Add(s, options...)
+	return Chain[R]{b: c.b}
+}
+
+// Emit registers an Emitter that consumes the output of the previous step.
+func Emit[T, R any](c Chain[T], s Emitter[T, R], options ...StepOption) Chain[R] {
+	c.b.Add(s, options...)
+	return Chain[R]{b: c.b}
+}
+
+// End registers a Sink as the last step of the flo and returns the underlying Builder.
+func End[T any](c Chain[T], s Sink[T], options ...StepOption) *Builder {
+	return c.b.Add(s, options...)
+}
+
+// Output registers ch as the output channel of the flo and returns the underlying Builder. It is the type safe
+// equivalent of WithOutput. Items are sent to ch without the use of reflection.
+func Output[R any](c Chain[R], ch chan<- R) *Builder {
+	c.b.outCh = ch
+	c.b.send = func(ctx context.Context, v interface{}) {
+		r, _ := v.(R)
+		select {
+		case ch <- r:
+		case <-ctx.Done():
+		}
+	}
+	return c.b
+}
+
+// Builder returns the Builder that backs the Chain.
+func (c Chain[R]) Builder() *Builder {
+	return c.b
+}
@@ -0,0 +1,112 @@
+package flo_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/codyoss/flo"
+)
+
+func TestChainBuildAndExecute(t *testing.T) {
+	inCh := make(chan int, 2)
+	inCh <- 2
+	inCh <- 3
+	close(inCh)
+	outCh := make(chan string, 2)
+
+	c := flo.Input(flo.NewBuilder(), inCh)
+	s := flo.Then(c, square)
+	err := flo.Output(flo.Then(s, itoa), outCh).BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	got := map[string]bool{<-outCh: true, <-outCh: true}
+	close(outCh)
+	if !got["4"] || !got["9"] {
+		t.Fatalf("got %v, want 4 and 9", got)
+	}
+}
+
+func TestChainBuilderValidate(t *testing.T) {
+	b := flo.End(flo.Begin(flo.NewBuilder(), start), end)
+	if err := b.Validate(); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestStagesMixWithSteps(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "hey"
+	close(inCh)
+	outCh := make(chan string, 1)
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithOutput(outCh)).
+		Add(flo.Stage[string, string](middle)).
+		Add(middle).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := <-outCh; got != "HEY" {
+		t.Fatalf("got %s, want HEY", got)
+	}
+}
+
+func TestStagesAreValidated(t *testing.T) {
+	err := flo.NewBuilder().
+		Add(flo.Source[string](start)).
+		Add(flo.Sink[int](func(ctx context.Context, i int) error { return nil })).
+		Validate()
+	if err == nil {
+		t.Errorf("got nil, want error")
+	}
+}
+
+func BenchmarkChain(b *testing.B) {
+	inCh := make(chan int, 5)
+	outCh := make(chan int, 5)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		c := flo.Input(flo.NewBuilder(flo.WithParallelism(5)), inCh)
+		c = flo.Then(c, addInts)
+		c = flo.Then(c, addInts)
+		c = flo.Then(c, addInts)
+		c = flo.Then(c, addInts)
+		c = flo.Then(c, addInts)
+		_ = flo.Output(c, outCh).BuildAndExecute(context.Background())
+		wg.Done()
+	}()
+	for n := 0; n < b.N; n++ {
+		inCh <- 1
+		<-outCh
+	}
+	close(inCh)
+	wg.Wait()
+	close(outCh)
+}
+
+func itoa(ctx context.Context, i int) (string, error) {
+	return strconv.Itoa(i), nil
+}
+
+func TestEmitterChain(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "a b"
+	close(inCh)
+	c := &collector{}
+
+	e := flo.Emit(flo.Input(flo.NewBuilder(), inCh), emitWords)
+	err := flo.End(e, c.collect).BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "a,b" {
+		t.Errorf("got %s, want a,b", got)
+	}
+}
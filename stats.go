@@ -0,0 +1,205 @@
+package flo
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the buckets call latencies are counted in.
+var latencyBounds = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Stats is a snapshot of what a flo is doing.
+type Stats struct {
+	// Steps holds the stats of each step, in the order they were registered.
+	Steps []StepStats
+}
+
+// StepStats is a snapshot of what a step is doing.
+type StepStats struct {
+	// Step is the position, starting at one, of the step within the Builder it was registered with.
+	Step int
+	// Name is the name of the step, see WithStepName.
+	Name string
+	// In is the number of pieces of data the step has taken in.
+	In int64
+	// Out is the number of pieces of data the step has sent along, either to the next step or to its branches.
+	Out int64
+	// Errored is the number of times the step returned an error.
+	Errored int64
+	// Dropped is the number of pieces of data the step chose not to send along, such as data removed by a filter or
+	// data that did not match any route.
+	Dropped int64
+	// Latency is the distribution of how long calls to the step took.
+	Latency LatencyStats
+	// QueueDepth is the number of pieces of data waiting to be taken in by the next step.
+	QueueDepth int
+	// QueueCapacity is the number of pieces of data that may wait to be taken in by the next step before the step
+	// blocks.
+	QueueCapacity int
+	// Workers is the number of workers the step was configured with.
+	Workers int
+	// ActiveWorkers is the number of workers currently processing data.
+	ActiveWorkers int64
+	// Branches holds the stats of the flos fed by a broadcast or route, or the producers of a merge. Branches and
+	// producers are keyed by their position, starting at one, and routes by their key. Should two keys print the same,
+	// such as DefaultRoute and a route keyed "default", their type is added, as in "default (string)".
+	Branches map[string]Stats
+}
+
+// LatencyStats is a distribution of how long calls to a step took.
+type LatencyStats struct {
+	// Count is the number of calls made to the step.
+	Count int64
+	// Sum is the total time spent in calls to the step.
+	Sum time.Duration
+	// Buckets holds the number of calls that took at most a given duration. The counts are cumulative, so each
+	// bucket includes the calls counted by the buckets before it.
+	Buckets []LatencyBucket
+}
+
+// LatencyBucket is the number of calls to a step that took at most UpperBound.
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// stepMetrics are the counters that back a StepStats. They are updated atomically by the step's workers.
+type stepMetrics struct {
+	in      int64
+	out     int64
+	errored int64
+	dropped int64
+	active  int64
+	count   int64
+	sum     int64
+	buckets [len(latencyBounds)]int64
+
+	mu      sync.Mutex
+	samples []error
+}
+
+// observe records the latency of a single call to the step.
+func (m *stepMetrics) observe(d time.Duration) {
+	atomic.AddInt64(&m.count, 1)
+	atomic.AddInt64(&m.sum, int64(d))
+	if i := sort.Search(len(latencyBounds), func(i int) bool { return d <= latencyBounds[i] }); i < len(latencyBounds) {
+		atomic.AddInt64(&m.buckets[i], 1)
+	}
+}
+
+// measureCall returns a callFn that records how long each call to fn takes.
+func (m *stepMetrics) measureCall(fn callFn) callFn {
+	return func(ctx context.Context, in interface{}) (interface{}, error) {
+		begin := time.Now()
+		value, err := fn(ctx, in)
+		m.observe(time.Since(begin))
+		return value, err
+	}
+}
+
+// measureItem returns an itemFn that counts the data fn takes in and sends along, along with how many workers are
+// running fn.
+func (m *stepMetrics) measureItem(fn itemFn) itemFn {
+	return func(ctx context.Context, input interface{}, send sendFn) {
+		atomic.AddInt64(&m.in, 1)
+		atomic.AddInt64(&m.active, 1)
+		fn(ctx, input, func(ctx context.Context, ch chan interface{}, value interface{}) bool {
+			if !send(ctx, ch, value) {
+				return false
+			}
+			atomic.AddInt64(&m.out, 1)
+			return true
+		})
+		atomic.AddInt64(&m.active, -1)
+	}
+}
+
+// Stats returns a snapshot of what each step of the flo is doing. It is safe to call while the flo is running, and
+// after it has shut down.
+func (b *Builder) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := Stats{Steps: make([]StepStats, len(b.steps))}
+	for i, s := range b.steps {
+		stats.Steps[i] = s.stats()
+	}
+	return stats
+}
+
+// branches returns the flos fed by a broadcast or route, or the producers of a merge. Branches and producers are keyed
+// by their position, starting at one, and routes by their key.
+func (s *stepRunner) branches() map[string]*Builder {
+	var branches map[string]*Builder
+	switch step := s.step.(type) {
+	case *broadcast:
+		branches = make(map[string]*Builder, len(step.branches))
+		for i, branch := range step.branches {
+			branches[strconv.Itoa(i+1)] = branch
+		}
+	case *router:
+		branches = make(map[string]*Builder, len(step.routes))
+		names := step.names()
+		for key, branch := range step.routes {
+			branches[names[key]] = branch
+		}
+	case *merge:
+		branches = make(map[string]*Builder, len(step.producers))
+		for i, producer := range step.producers {
+			branches[strconv.Itoa(i+1)] = producer
+		}
+	}
+	return branches
+}
+
+// stats returns a snapshot of what the step is doing.
+func (s *stepRunner) stats() StepStats {
+	st := StepStats{Step: s.index, Name: s.name, Workers: s.parallelism}
+	if s.outCh != nil {
+		st.QueueDepth = len(s.outCh)
+		st.QueueCapacity = cap(s.outCh)
+	}
+
+	if _, ok := s.step.(*merge); ok {
+		st.Workers = 0
+	}
+	if branches := s.branches(); branches != nil {
+		st.Branches = make(map[string]Stats, len(branches))
+		for key, branch := range branches {
+			st.Branches[key] = branch.Stats()
+		}
+	}
+
+	m := s.metrics
+	st.In = atomic.LoadInt64(&m.in)
+	st.Out = atomic.LoadInt64(&m.out)
+	st.Errored = atomic.LoadInt64(&m.errored)
+	st.Dropped = atomic.LoadInt64(&m.dropped)
+	st.ActiveWorkers = atomic.LoadInt64(&m.active)
+	st.Latency.Count = atomic.LoadInt64(&m.count)
+	st.Latency.Sum = time.Duration(atomic.LoadInt64(&m.sum))
+	st.Latency.Buckets = make([]LatencyBucket, len(latencyBounds))
+	var cumulative int64
+	for i, bound := range latencyBounds {
+		cumulative += atomic.LoadInt64(&m.buckets[i])
+		st.Latency.Buckets[i] = LatencyBucket{UpperBound: bound, Count: cumulative}
+	}
+	return st
+}
@@ -0,0 +1,113 @@
+package flo_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestStats(t *testing.T) {
+	inCh := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		inCh <- i
+	}
+	close(inCh)
+
+	b := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(2)).
+		Filter(isEven).
+		Add(func(ctx context.Context, i int) (int, error) {
+			if i == 4 {
+				return 0, fmt.Errorf("four")
+			}
+			return i, nil
+		}).
+		Broadcast(flo.NewBuilder().Add(func(ctx context.Context, i int) error { return nil }))
+
+	// stats may be read while the flo is being started and run
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			b.Stats()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	if err := b.BuildAndExecute(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	<-done
+
+	stats := b.Stats()
+	if len(stats.Steps) != 3 {
+		t.Fatalf("got %d steps, want 3", len(stats.Steps))
+	}
+	tests := []struct {
+		name                      string
+		got                       flo.StepStats
+		step                      int
+		in, out, errored, dropped int64
+	}{
+		{"filter", stats.Steps[0], 1, 10, 5, 0, 5},
+		{"in out", stats.Steps[1], 2, 5, 4, 1, 0},
+		{"broadcast", stats.Steps[2], 3, 4, 4, 0, 0},
+		{"branch", stats.Steps[2].Branches["1"].Steps[0], 1, 4, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.got
+			if got.Step != tt.step || got.In != tt.in || got.Out != tt.out || got.Errored != tt.errored || got.Dropped != tt.dropped {
+				t.Errorf("got step %d with %d/%d/%d/%d, want step %d with %d/%d/%d/%d", got.Step,
+					got.In, got.Out, got.Errored, got.Dropped, tt.step, tt.in, tt.out, tt.errored, tt.dropped)
+			}
+			if got.ActiveWorkers != 0 {
+				t.Errorf("got %d active workers, want 0", got.ActiveWorkers)
+			}
+		})
+	}
+
+	latency := stats.Steps[1].Latency
+	if latency.Count != 5 {
+		t.Errorf("got %d calls, want 5", latency.Count)
+	}
+	if last := latency.Buckets[len(latency.Buckets)-1]; last.Count != 5 || last.UpperBound != 10*time.Second {
+		t.Errorf("got %+v, want 5 calls within 10s", last)
+	}
+	if stats.Steps[0].Workers != 2 || stats.Steps[0].QueueCapacity != 2 {
+		t.Errorf("got %d workers with a queue of %d, want 2 and 2", stats.Steps[0].Workers, stats.Steps[0].QueueCapacity)
+	}
+}
+
+func TestStatsRouteBranches(t *testing.T) {
+	b := flo.NewBuilder().Add(start).Route(byLength, map[interface{}]*flo.Builder{
+		1:                flo.NewBuilder().Add(end),
+		flo.DefaultRoute: flo.NewBuilder().Add(end),
+	})
+
+	branches := b.Stats().Steps[1].Branches
+	if _, ok := branches["1"]; !ok {
+		t.Errorf("got %v, want a branch keyed 1", branches)
+	}
+	if _, ok := branches["default"]; !ok {
+		t.Errorf("got %v, want a branch keyed default", branches)
+	}
+}
+
+func TestStatsRouteBranchesCollide(t *testing.T) {
+	b := flo.NewBuilder().Add(start).Route(func(ctx context.Context, s string) (interface{}, error) {
+		return s, nil
+	}, map[interface{}]*flo.Builder{
+		"default":        flo.NewBuilder().Add(end),
+		flo.DefaultRoute: flo.NewBuilder().Add(end),
+	})
+
+	branches := b.Stats().Steps[1].Branches
+	if len(branches) != 2 {
+		t.Fatalf("got %v, want 2 branches", branches)
+	}
+	if _, ok := branches["default (string)"]; !ok {
+		t.Errorf("got %v, want a branch keyed default (string)", branches)
+	}
+}
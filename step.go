@@ -2,8 +2,13 @@ package flo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type stepType int
@@ -13,32 +18,103 @@ const (
 	onlyOut
 	onlyIn
 	inOut
+	fanOut
+	fanIn
+	route
+	filter
+	flatMap
+	emitter
+	batch
 )
 
+// isTransform reports whether a step of this type both takes in data and sends data along to the next step.
+func (st stepType) isTransform() bool {
+	switch st {
+	case inOut, filter, flatMap, emitter, batch:
+		return true
+	}
+	return false
+}
+
+// hasOutput reports whether a step of this type sends data along to the next step.
+func (st stepType) hasOutput() bool {
+	return st != onlyIn && st != fanOut && st != route
+}
+
 // Step should be a function. The func can look like any of the following examples:
 //  func(context.Context) (R, error)
 //  func(context.Context, T) (R, error)
 //  func(context.Context, T) error
+//  func(context.Context, T, func(R) error) error
 //
-// Basically, a Step must at least take a context as its first input parameter and return at least an error. The middle
-// example may be used at any point in the Flo. The first example may only be used as the first step of a Flo, and the
-// last example may only be used as the last step of a Flo.
+// Basically, a Step must at least take a context as its first input parameter and return at least an error. The second
+// and last examples may be used at any point in the Flo. The first example may only be used as the first step of a
+// Flo, and the third example may only be used as the last step of a Flo.
 type Step interface{}
 
-// ErrorHandler is a function that takes an error. It allows the user to do something when an error occurs.
+// ErrorHandler is a function that takes an error. It allows the user to do something when an error occurs. Errors
+// returned by steps are wrapped in a StepError.
 type ErrorHandler func(error)
 
 type processFn func(context.Context)
 
+// itemFn processes a single piece of data the step takes in, using send to pass along anything it produces.
+type itemFn func(ctx context.Context, input interface{}, send sendFn)
+
+// sendFn passes a piece of data along to a channel. It reports false if the data could not be sent because ctx was
+// canceled, for instance because the flo was aborted.
+type sendFn func(ctx context.Context, ch chan interface{}, value interface{}) bool
+
+// callFn is the compiled form of a Step. For steps that do not take an input the in value is ignored, and for steps
+// that do not produce an output the returned value is always nil.
+type callFn func(ctx context.Context, in interface{}) (interface{}, error)
+
+// emitKey is the context key under which an emitter step's workers store the func used to send data along. The func
+// is given the context the step was called with.
+type emitKey struct{}
+
+// errEmitClosed is returned by an emit func called after the worker has moved on from the data it was called for, for
+// instance because the call timed out.
+var errEmitClosed = errors.New("emit called after the step stopped processing its data")
+
+// typedStep is implemented by the type safe Source, Stage and Sink types so they can be invoked without reflection.
+type typedStep interface {
+	invoke(ctx context.Context, in interface{}) (interface{}, error)
+}
+
 // stepRunner orchestrates a worker pool of steps.
 type stepRunner struct {
 	parallelism int
 	inCh        chan interface{}
 	outCh       chan interface{}
 	step        Step
+	call        callFn
 	wg          *sync.WaitGroup
 	sType       stepType
+	outputs     []reflect.Type
+	window      int
 	errHandler  func(error)
+	retry       *RetryPolicy
+	repanic     bool
+	timeout     time.Duration
+	limiter     *Limiter
+	breaker     *breaker
+	metrics     *stepMetrics
+	middleware  []Middleware
+	name        string
+	funcName    string
+	// named is true if the step was given a name with WithStepName.
+	named bool
+	// index is the position, starting at one, of the step within its Builder.
+	index int
+
+	deadLetterHandler DeadLetterHandler
+	// failer is set when the step is part of a fail fast flo.
+	failer *failer
+	// background tracks calls to the step that timed out but have yet to return.
+	background sync.WaitGroup
+	// aborted is closed once the flo is killed, at which point calls that timed out are no longer waited on.
+	aborted <-chan struct{}
 }
 
 // StepOption configures how a Step will be run.
@@ -62,6 +138,84 @@ func WithStepErrorHandler(handler ErrorHandler) StepOption {
 	}
 }
 
+// WithStepName configures the name of a step. The name is used to identify the step in errors, metrics and stats. By
+// default a step is named after its function, for example a step registered with Add(parse) is named "parse". Names
+// given with WithStepName must be unique within a Builder, while default names may repeat.
+func WithStepName(name string) StepOption {
+	return func(s *stepRunner) {
+		s.name = name
+	}
+}
+
+// defaultStepName returns the name of a step that was not given one with WithStepName.
+func defaultStepName(step Step) string {
+	switch st := step.(type) {
+	case *broadcast:
+		return "broadcast"
+	case *merge:
+		return "merge"
+	case *batcher:
+		return "batch"
+	case *router:
+		step = st.fn
+	case *predicate:
+		step = st.fn
+	case *flatMapper:
+		step = st.fn
+	}
+
+	name := funcName(step)
+	if name == "" {
+		return fmt.Sprintf("%T", step)
+	}
+	// trim the package path and name, along with the suffix given to method values
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = name[strings.Index(name, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// validationError describes an error found while validating the step.
+func (s *stepRunner) validationError(err error) error {
+	return fmt.Errorf(stepFmt, s.index, s.name, err)
+}
+
+// inputType returns the type of data the step takes in, or nil if it does not take in any.
+func (s *stepRunner) inputType() reflect.Type {
+	switch st := s.step.(type) {
+	case *router:
+		return reflect.TypeOf(st.fn).In(1)
+	case *predicate:
+		return reflect.TypeOf(st.fn).In(1)
+	case *flatMapper:
+		return reflect.TypeOf(st.fn).In(1)
+	case *batcher:
+		return st.elem
+	}
+
+	switch s.sType {
+	case inOut, onlyIn, emitter:
+		return reflect.TypeOf(s.step).In(1)
+	}
+	return nil
+}
+
+// outputType returns the type of data the step sends to the next step, or nil if it does not send any.
+func (s *stepRunner) outputType() reflect.Type {
+	switch s.sType {
+	case onlyOut, inOut:
+		return reflect.TypeOf(s.step).Out(0)
+	case filter:
+		return s.inputType()
+	case flatMap:
+		return reflect.TypeOf(s.step.(*flatMapper).fn).Out(0).Elem()
+	case emitter:
+		return reflect.TypeOf(s.step).In(2).In(0)
+	case batch:
+		return reflect.SliceOf(s.step.(*batcher).elem)
+	}
+	return nil
+}
+
 // registerInput is used to tell the worker pool what channel to listen for data on.
 func (s *stepRunner) registerInput(in chan interface{}) {
 	s.inCh = in
@@ -77,8 +231,23 @@ func (s *stepRunner) output() chan interface{} {
 
 // start launches the pool of workers for the given step.
 func (s *stepRunner) start(ctx context.Context) {
+	s.failer, _ = ctx.Value(failerKey{}).(*failer)
+	s.aborted = ctx.Done()
+	switch st := s.step.(type) {
+	case *merge:
+		st.start(ctx, s.wg, s.output())
+		return
+	case brancher:
+		st.start(ctx)
+	}
+	s.compile()
 	fn := s.determineProcessFn()
-	for i := 0; i < s.parallelism; i++ {
+	workers := s.parallelism
+	if s.ordered() {
+		// processOrdered manages its own pool of workers
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
 		go func() {
 			fn(ctx)
 			s.wg.Done()
@@ -87,16 +256,132 @@ func (s *stepRunner) start(ctx context.Context) {
 	}
 }
 
+// compile determines how the step will be invoked for each piece of data.
+func (s *stepRunner) compile() {
+	switch st := s.step.(type) {
+	case *router:
+		s.call = compileStep(st.fn, inOut)
+		s.funcName = funcName(st.fn)
+	case *predicate:
+		s.call = compileStep(st.fn, inOut)
+		s.funcName = funcName(st.fn)
+	case *flatMapper:
+		s.call = compileStep(st.fn, inOut)
+		s.funcName = funcName(st.fn)
+	case *batcher:
+		st.compile()
+	default:
+		s.call = compileStep(s.step, s.sType)
+		s.funcName = funcName(s.step)
+	}
+	if s.call == nil {
+		return
+	}
+	if !s.repanic {
+		s.call = recoverCall(s.call)
+	}
+	s.call = s.metrics.measureCall(s.call)
+	if s.timeout > 0 {
+		s.call = timeoutCall(s.call, s.timeout, &s.background)
+	}
+	if s.limiter != nil {
+		s.call = limitCall(s.call, s.limiter)
+	}
+	s.call = s.stepErrorCall(s.call)
+	if s.retry != nil {
+		s.call = s.retry.wrap(s.call)
+	}
+	if s.breaker != nil {
+		// a source has no data to fail fast on, failing fast would only report an error on every call until the cool down
+		// passes
+		s.call = s.breaker.wrap(s.call, s.sType == onlyOut)
+	}
+	if len(s.middleware) > 0 {
+		s.call = wrapMiddleware(s.call, StepInfo{Step: s.index, Name: s.name}, s.middleware)
+	}
+}
+
+// compileStep turns a Step into a callFn. Steps built with the Source, Stage and Sink types are called directly, all
+// other steps are called via reflection.
+func compileStep(step Step, st stepType) callFn {
+	if ts, ok := step.(typedStep); ok {
+		return ts.invoke
+	}
+
+	fn := reflect.ValueOf(step)
+	switch st {
+	case onlyOut:
+		return func(ctx context.Context, _ interface{}) (interface{}, error) {
+			vs := fn.Call([]reflect.Value{reflect.ValueOf(ctx)})
+			err, _ := vs[1].Interface().(error)
+			return vs[0].Interface(), err
+		}
+	case inOut:
+		return func(ctx context.Context, in interface{}) (interface{}, error) {
+			vs := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(in)})
+			err, _ := vs[1].Interface().(error)
+			return vs[0].Interface(), err
+		}
+	case onlyIn:
+		return func(ctx context.Context, in interface{}) (interface{}, error) {
+			vs := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(in)})
+			err, _ := vs[0].Interface().(error)
+			return nil, err
+		}
+	case emitter:
+		emitType := fn.Type().In(2)
+		return func(ctx context.Context, in interface{}) (interface{}, error) {
+			emit, _ := ctx.Value(emitKey{}).(func(context.Context, interface{}) error)
+			emitFn := reflect.MakeFunc(emitType, func(args []reflect.Value) []reflect.Value {
+				err := emit(ctx, args[0].Interface())
+				return []reflect.Value{reflect.ValueOf(&err).Elem()}
+			})
+			vs := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(in), emitFn})
+			err, _ := vs[0].Interface().(error)
+			return nil, err
+		}
+	}
+	return nil
+}
+
 // determineProcessFn figures out which type of processing to do based on the step's type.
 func (s *stepRunner) determineProcessFn() processFn {
-	var fn processFn
 	switch s.sType {
 	case onlyOut:
-		fn = s.processOnlyOut
+		return s.processOnlyOut
+	case batch:
+		return s.processBatch
+	}
+
+	fn := s.metrics.measureItem(s.determineItemFn())
+	if s.ordered() {
+		return func(ctx context.Context) {
+			s.processOrdered(ctx, fn)
+		}
+	}
+	return func(ctx context.Context) {
+		s.processItems(ctx, fn)
+	}
+}
+
+// determineItemFn figures out how to process each piece of data based on the step's type.
+func (s *stepRunner) determineItemFn() itemFn {
+	var fn itemFn
+	switch s.sType {
 	case inOut:
-		fn = s.processInOut
+		fn = s.handleInOut
 	case onlyIn:
-		fn = s.processOnlyIn
+		fn = s.handleOnlyIn
+	case fanOut:
+		fn = s.handleFanOut
+	case route:
+		fn = s.handleRoute
+	case filter:
+		fn = s.handleFilter
+	case flatMap:
+		fn = s.handleFlatMap
+	case emitter:
+		fn = s.handleEmit
 	}
 
 	return fn
@@ -104,59 +389,159 @@ func (s *stepRunner) determineProcessFn() processFn {
 
 // processOnlyOut is a step that emits data. Could only be the first step in the flo.
 func (s *stepRunner) processOnlyOut(ctx context.Context) {
+	intake := intakeContext(ctx)
 	for {
 		select {
-		case <-ctx.Done():
+		case <-intake.Done():
 			return
 		default:
-			vs := reflect.ValueOf(s.step).Call([]reflect.Value{reflect.ValueOf(ctx)})
-			value := vs[0].Interface()
-			err, ok := vs[1].Interface().(error)
-			if ok && err != nil {
-				if s.errHandler != nil {
-					s.errHandler(err)
+			// the source is called with the intake context, so stopping the flo interrupts a source waiting on data
+			atomic.AddInt64(&s.metrics.active, 1)
+			value, err := s.call(intake, nil)
+			atomic.AddInt64(&s.metrics.active, -1)
+			if err != nil {
+				if intake.Err() != nil && errors.Is(err, intake.Err()) {
+					// the source was interrupted by the flo stopping, which is not worth reporting
+					return
 				}
+				s.reportError(nil, err)
 				continue
 			}
-			s.outCh <- value
+			if !send(ctx, s.outCh, value) {
+				return
+			}
+			atomic.AddInt64(&s.metrics.out, 1)
 		}
 	}
 }
 
-// processInOut is a step that has both input and output. Could be any step in the flo.
-func (s *stepRunner) processInOut(ctx context.Context) {
-	for input := range s.inCh {
-		vs := reflect.ValueOf(s.step).Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(input)})
-		value := vs[0].Interface()
-		err, ok := vs[1].Interface().(error)
-		if ok && err != nil {
-			if s.errHandler != nil {
-				s.errHandler(err)
-			}
-			continue
+// processItems handles each piece of data the step takes in as it arrives, until the step's input channel is closed or
+// the flo is aborted.
+func (s *stepRunner) processItems(ctx context.Context, fn itemFn) {
+	for {
+		input, ok := receive(ctx, s.inCh)
+		if !ok {
+			return
 		}
-		s.outCh <- value
+		fn(ctx, input, send)
 	}
 }
 
-// processOnlyIn is a step that consumes data. Could only be the last step in the flo.
-func (s *stepRunner) processOnlyIn(ctx context.Context) {
-	for input := range s.inCh {
-		vs := reflect.ValueOf(s.step).Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(input)})
-		err, ok := vs[0].Interface().(error)
-		if ok && err != nil {
-			if s.errHandler != nil {
-				s.errHandler(err)
-			}
-			continue
+// send passes a piece of data along to a channel. It gives up and reports false should ctx be canceled first, so a
+// step never blocks forever on a consumer that has gone away.
+func send(ctx context.Context, ch chan interface{}, value interface{}) bool {
+	select {
+	case ch <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// receive takes the next piece of data from a channel. It reports false once the channel is closed or ctx is canceled.
+func receive(ctx context.Context, ch chan interface{}) (interface{}, bool) {
+	select {
+	case value, ok := <-ch:
+		return value, ok
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// handleInOut is a step that has both input and output. Could be any step in the flo.
+func (s *stepRunner) handleInOut(ctx context.Context, input interface{}, send sendFn) {
+	value, err := s.call(stepContext(ctx), input)
+	if err != nil {
+		s.reportError(input, err)
+		return
+	}
+	send(ctx, s.outCh, value)
+}
+
+// handleEmit is a step that sends along data through an emit func rather than returning it. Could be any step in the
+// flo. The emit func blocks until the next step is ready to receive the data, and fails once the context is canceled.
+// Once the call returns, or is given up on because it timed out, the emit func stops sending along data.
+func (s *stepRunner) handleEmit(ctx context.Context, input interface{}, send sendFn) {
+	var (
+		mu     sync.Mutex
+		closed bool
+	)
+	emit := func(callCtx context.Context, value interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return errEmitClosed
+		}
+		if err := callCtx.Err(); err != nil {
+			return err
+		}
+		if !send(callCtx, s.outCh, value) {
+			return callCtx.Err()
 		}
+		return nil
+	}
+	_, err := s.call(context.WithValue(stepContext(ctx), emitKey{}, emit), input)
+	// a call left running in the background is bound by its own context, so it lets go of mu soon after timing out
+	mu.Lock()
+	closed = true
+	mu.Unlock()
+	if err != nil {
+		s.reportError(input, err)
+	}
+}
+
+// handleOnlyIn is a step that consumes data. Could only be the last step in the flo.
+func (s *stepRunner) handleOnlyIn(ctx context.Context, input interface{}, send sendFn) {
+	_, err := s.call(stepContext(ctx), input)
+	if err != nil {
+		s.reportError(input, err)
+	}
+}
+
+// reportError wraps an error returned by the step in a StepError, passing it to the error handler and the data it failed
+// to process to the dead letter handler, if there are any.
+func (s *stepRunner) reportError(input interface{}, err error) {
+	atomic.AddInt64(&s.metrics.errored, 1)
+	err = s.stepError(input, 0, err)
+	s.metrics.sample(err)
+	if s.errHandler != nil {
+		s.errHandler(err)
+	}
+	if s.deadLetterHandler != nil {
+		s.deadLetterHandler(DeadLetter{Input: input, Step: s.index, Name: s.name, Err: err, Time: time.Now()})
+	}
+	if s.failer != nil {
+		s.failer.record(err)
+	}
+}
+
+// awaitBackground waits for calls to the step that timed out to return, unless the flo is killed first.
+func (s *stepRunner) awaitBackground() {
+	if s.timeout <= 0 {
+		return
+	}
+	returned := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-s.aborted:
 	}
 }
 
 // awaitShutdown gracefully shuts down the pool of workers.
 func (s *stepRunner) awaitShutdown() {
+	if m, ok := s.step.(*merge); ok {
+		m.awaitShutdown()
+	}
 	s.wg.Wait()
+	s.awaitBackground()
 	if s.outCh != nil {
 		close(s.outCh)
 	}
+	if br, ok := s.step.(brancher); ok {
+		br.awaitShutdown()
+	}
 }
@@ -17,6 +17,7 @@ func BenchmarkStep(b *testing.B) {
 		wg:          &sync.WaitGroup{},
 		step:        addInt,
 		parallelism: 1,
+		metrics:     &stepMetrics{},
 	}
 	sr.start(context.Background())
 
@@ -0,0 +1,62 @@
+package flo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStepTimeout is reported when a step takes longer to process a piece of data than the timeout configured with
+// WithStepTimeout. Use errors.Is to check for it.
+var ErrStepTimeout = errors.New("step timed out")
+
+// WithStepTimeout configures how long a step may take to process a single piece of data. Each call to the step is given
+// a context that is canceled once the timeout passes. Should the step not return by then, ErrStepTimeout is reported to
+// the error handler and the worker moves on to the next piece of data, leaving the step to return in the background.
+// The flo still waits for such calls to return before it shuts down, unless it is killed or aborted, in which case they
+// may outlive it. A timeout of zero or less, the default, disables timeouts. When combined with WithStepRetry each
+// attempt is given its own timeout.
+func WithStepTimeout(timeout time.Duration) StepOption {
+	return func(s *stepRunner) {
+		if timeout < 0 {
+			timeout = 0
+		}
+		s.timeout = timeout
+	}
+}
+
+// timeoutCall returns a callFn that stops waiting on fn once the timeout passes. Each call to fn is tracked with wg, so
+// calls left running in the background can be waited on.
+func timeoutCall(fn callFn, timeout time.Duration, wg *sync.WaitGroup) callFn {
+	type result struct {
+		value interface{}
+		err   error
+	}
+	return func(parent context.Context, in interface{}) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+
+		done := make(chan result, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := fn(ctx, in)
+			done <- result{value, err}
+		}()
+		select {
+		case r := <-done:
+			if errors.Is(r.err, context.DeadlineExceeded) && ctx.Err() != nil && parent.Err() == nil {
+				// the step gave up on its own once the timeout passed
+				return nil, fmt.Errorf("%w after %v: %v", ErrStepTimeout, timeout, r.err)
+			}
+			return r.value, r.err
+		case <-ctx.Done():
+			if err := parent.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w after %v", ErrStepTimeout, timeout)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package flo_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codyoss/flo"
+)
+
+func TestStepTimeout(t *testing.T) {
+	inCh := make(chan string, 2)
+	inCh <- "hang"
+	inCh <- "a"
+	close(inCh)
+	release := make(chan struct{})
+	// the flo waits for the hung call to return before shutting down
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	var returned int32
+	c := &collector{}
+	var errs []error
+	var mu sync.Mutex
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(1)).
+		Add(func(ctx context.Context, s string) (string, error) {
+			if s == "hang" {
+				// ignores the context, as a misbehaving call to a downstream service might
+				<-release
+				atomic.StoreInt32(&returned, 1)
+			}
+			return middle(ctx, s)
+		}, flo.WithStepTimeout(10*time.Millisecond), flo.WithStepErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		})).
+		Add(c.collect).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if got := c.sorted(); got != "A" {
+		t.Errorf("got %s, want A", got)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], flo.ErrStepTimeout) {
+		t.Errorf("got %v, want a single %v", errs, flo.ErrStepTimeout)
+	}
+	if atomic.LoadInt32(&returned) != 1 {
+		t.Errorf("got the hung call still running, want it to have returned")
+	}
+}
+
+func TestStepTimeoutKill(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	timedOut := make(chan struct{}, 1)
+
+	f, err := flo.NewBuilder().
+		Add(start).
+		Add(func(ctx context.Context, s string) error {
+			// ignores the context, so the call never returns while the test runs
+			<-release
+			return nil
+		}, flo.WithStepTimeout(time.Millisecond), flo.WithStepErrorHandler(func(err error) {
+			select {
+			case timedOut <- struct{}{}:
+			default:
+			}
+		})).
+		Start(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	<-timedOut
+	f.Kill()
+	select {
+	case <-f.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("flo did not shut down after being killed")
+	}
+}
+
+func TestStepTimeoutSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	eh := &errHandle{}
+
+	err := flo.NewBuilder(flo.WithErrorHandler(func(err error) {
+		if errors.Is(err, flo.ErrStepTimeout) {
+			eh.handleError(err)
+			cancel()
+		}
+	})).
+		Add(func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}, flo.WithStepTimeout(time.Millisecond), flo.WithStepParallelism(1)).
+		Add(end).
+		BuildAndExecute(ctx)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if !eh.hasHandled {
+		t.Errorf("got false, want true")
+	}
+}
+
+func TestStepTimeoutEmitter(t *testing.T) {
+	inCh := make(chan string, 1)
+	inCh <- "a"
+	close(inCh)
+	release := make(chan struct{})
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	var received int32
+	eh := &errHandle{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(1)).
+		Add(func(ctx context.Context, s string, emit func(string) error) error {
+			for i := 0; i < 5; i++ {
+				if err := emit(s); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, flo.WithStepTimeout(10*time.Millisecond), flo.WithStepErrorHandler(eh.handleError)).
+		Add(func(ctx context.Context, s string) error {
+			<-release
+			atomic.AddInt32(&received, 1)
+			return nil
+		}).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// one item is taken in by the blocked step and one fits in its queue, the emit that was blocked once the timeout
+	// passed must not be sent along afterwards
+	if got := atomic.LoadInt32(&received); got != 2 {
+		t.Errorf("got %d received, want 2", got)
+	}
+	if !eh.hasHandled {
+		t.Errorf("got false, want true")
+	}
+}
+
+func TestStepTimeoutOrderedEmitter(t *testing.T) {
+	inCh := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		inCh <- i
+	}
+	close(inCh)
+	var mu sync.Mutex
+	got := map[int]int{}
+	timedOut := map[int]bool{}
+
+	err := flo.NewBuilder(flo.WithInput(inCh), flo.WithParallelism(4), flo.WithOrdering(4),
+		flo.WithErrorHandler(func(err error) {
+			var se *flo.StepError
+			if errors.As(err, &se) && errors.Is(err, flo.ErrStepTimeout) {
+				mu.Lock()
+				defer mu.Unlock()
+				timedOut[se.Input.(int)] = true
+			}
+		})).
+		Add(func(ctx context.Context, i int, emit func(int) error) error {
+			for j := 0; j < 5; j++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+				time.Sleep(time.Millisecond)
+			}
+			return nil
+		}, flo.WithStepTimeout(2*time.Millisecond)).
+		Add(func(ctx context.Context, i int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[i]++
+			return nil
+		}).
+		BuildAndExecute(context.Background())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		if !timedOut[i] && got[i] != 5 {
+			t.Errorf("got %d items for %d, want 5 as it did not time out", got[i], i)
+		}
+	}
+}